@@ -2,14 +2,18 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"math/rand/v2"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/erfnzdeh/git-retime/internal/compile"
@@ -23,13 +27,223 @@ type options struct {
 	randomize             string
 	randomizeAllowParadox bool
 	splitDates            bool
+	round                 string
+	stripSeconds          bool
+	minGap                string
+	scale                 string
+	scaleAnchor           string
+	fitInto               string
+	jitter                string
+	seed                  string
+	coarsen               string
+	tz                    string
+	dryRun                bool
+	author                string
+	grep                  string
+	since                 string
+	until                 string
+	hideUnmatched         bool
+	branch                string
+	outputBranch          string
+	forcePublished        bool
+	autostash             bool
+	updateRefs            bool
+	updateTags            bool
+	retimeTags            bool
+	audit                 bool
+	restore               bool
+	restoreCommitter      optionalValue
+	unmatched             string
+	printMap              bool
+	runHooks              bool
+	noVerify              bool // default, accepted for clarity
+	noAutoAbort           bool
+	unique                bool
 	interactive           bool // no-op, accepted for UX compatibility
 }
 
-func Run(args []string) error {
+// optionalValue is a flag that can be given bare (--flag) or with a value
+// (--flag=value).
+type optionalValue struct {
+	set   bool
+	value string
+}
+
+func (o *optionalValue) String() string { return o.value }
+
+func (o *optionalValue) Set(v string) error {
+	o.set = true
+	if v != "true" {
+		o.value = v
+	}
+	return nil
+}
+
+func (o *optionalValue) IsBoolFlag() bool { return true }
+
+// scope is the range of commits being retimed and where the result goes.
+type scope struct {
+	commits   []git.CommitInfo
+	base      string
+	needsRoot bool
+	target    git.Target
+	autostash bool
+	// updateRefs and updateTags move other branches and tags pointing into
+	// the range; retimeTags also shifts annotated tags' tagger dates.
+	updateRefs bool
+	updateTags bool
+	retimeTags bool
+	// audit records the original dates of retimed commits in git notes.
+	audit bool
+	// printMap writes the old->new commit map to stdout.
+	printMap bool
+	runHooks bool
+	// noAutoAbort leaves a failed rebase stopped for --continue or --abort.
+	noAutoAbort bool
+}
+
+// adjustments are whole-plan transformations applied once timestamps are
+// resolved, regardless of which mode produced them.
+type adjustments struct {
+	jitter       time.Duration
+	round        *timestamp.Rounding
+	stripSeconds bool
+	coarsen      time.Duration
+	tz           *time.Location
+	minGap       time.Duration
+}
+
+func parseAdjustments(opts options) (adjustments, error) {
+	var adj adjustments
+	if opts.jitter != "" {
+		amount, err := parseDuration(opts.jitter)
+		if err != nil {
+			return adj, fmt.Errorf("invalid --jitter value: %w", err)
+		}
+		adj.jitter = amount
+	}
+	if opts.round != "" {
+		r, err := timestamp.ParseRounding(opts.round)
+		if err != nil {
+			return adj, fmt.Errorf("invalid --round value: %w", err)
+		}
+		adj.round = &r
+	}
+	adj.stripSeconds = opts.stripSeconds
+	if opts.coarsen != "" {
+		unit, err := parseDuration(opts.coarsen)
+		if err != nil {
+			return adj, fmt.Errorf("invalid --coarsen value: %w", err)
+		}
+		adj.coarsen = unit
+		// Coarsening is a privacy measure: drop the original offset even
+		// when no zone was requested.
+		adj.tz = time.UTC
+	}
+	if opts.tz != "" {
+		loc, err := parseZone(opts.tz)
+		if err != nil {
+			return adj, fmt.Errorf("invalid --tz value: %w", err)
+		}
+		adj.tz = loc
+	}
+	if opts.minGap != "" {
+		gap, err := parseDuration(opts.minGap)
+		if err != nil {
+			return adj, fmt.Errorf("invalid --min-gap value: %w", err)
+		}
+		adj.minGap = gap
+	}
+	// Git stores whole seconds, so one second apart is the smallest gap
+	// that keeps timestamps distinct.
+	if opts.unique && adj.minGap < time.Second {
+		adj.minGap = time.Second
+	}
+	return adj, nil
+}
+
+// apply adjusts the plan for the selected commits and merges it into the
+// whole range. Jitter and the minimum gap work across every replayed
+// commit, so a retimed commit can neither jump past nor tie with an
+// unselected neighbour, but only the selected commits are moved.
+func (a adjustments) apply(all []git.CommitInfo, commits []timestamp.Commit) []timestamp.Commit {
+	full := mergePlan(all, commits)
+	movable := make([]bool, len(all))
+	for i, c := range all {
+		movable[i] = !c.Context
+	}
+
+	if a.jitter > 0 {
+		timestamp.Jitter(full, a.jitter, movable)
+	}
+	// The remaining adjustments only touch the selected commits.
+	selected := make([]timestamp.Commit, 0, len(commits))
+	for i := range full {
+		if movable[i] {
+			selected = append(selected, full[i])
+		}
+	}
+	if a.round != nil {
+		timestamp.RoundAll(selected, *a.round)
+	}
+	if a.stripSeconds {
+		timestamp.RoundAll(selected, timestamp.Rounding{Unit: time.Minute, Mode: timestamp.RoundFloor})
+	}
+	if a.tz != nil {
+		timestamp.Coarsen(selected, a.coarsen, a.tz)
+	}
+	full = mergePlan(all, selected)
+
+	// Gap enforcement runs last so that rounding cannot collapse commits
+	// back onto the same timestamp.
+	if a.minGap > 0 {
+		timestamp.EnforceMinGap(full, a.minGap, movable)
+	}
+	return full
+}
+
+// parseDuration parses an unsigned duration using the shift units
+// (e.g. "3m", "1h30m").
+func parseDuration(expr string) (time.Duration, error) {
+	return timestamp.ParseShift("+" + strings.TrimPrefix(expr, "+"))
+}
+
+// parseZone accepts "UTC", "local" or an IANA zone name.
+func parseZone(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+func Run(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "tags" {
+		return runTags(ctx, args[1:])
+	}
+	if len(args) > 0 && args[0] == "--continue" {
+		if len(args) > 1 {
+			return errors.New("--continue takes no other arguments")
+		}
+		return continueSession(ctx)
+	}
+	if len(args) > 0 && args[0] == "--abort" {
+		if len(args) > 1 {
+			return errors.New("--abort takes no other arguments")
+		}
+		return abortSession(ctx)
+	}
+	return run(ctx, args, nil)
+}
+
+// run retimes as described by args. resume is the saved session being
+// continued, or nil.
+func run(ctx context.Context, args []string, resume *session) error {
 	// Reorder args so flags come before the positional revision argument,
 	// allowing users to write "git retime HEAD~3 --shift +2h" naturally.
-	flagArgs, positional := reorderArgs(args)
+	flagArgs, positional, paths := reorderArgs(args)
 
 	fs := flag.NewFlagSet("git-retime", flag.ContinueOnError)
 	var opts options
@@ -38,16 +252,54 @@ func Run(args []string) error {
 	fs.StringVar(&opts.randomize, "randomize", "", "randomize time-of-day within range (e.g. 09:00-17:00)")
 	fs.BoolVar(&opts.randomizeAllowParadox, "randomize-allow-paradox", false, "allow non-monotonic times when randomizing (by default times are sorted within each day)")
 	fs.BoolVar(&opts.splitDates, "split-dates", false, "edit author and committer dates independently")
+	fs.StringVar(&opts.scale, "scale", "", "multiply every gap between commits by a factor (e.g. 0.5)")
+	fs.StringVar(&opts.scaleAnchor, "scale-anchor", "first", "fixed point for --scale: first, last or a timestamp")
+	fs.StringVar(&opts.fitInto, "fit-into", "", "stretch or compress the range to fit a span (e.g. \"2026-02-23 09:00:00..2026-02-27 18:00:00\")")
+	fs.StringVar(&opts.jitter, "jitter", "", "move each commit by up to this much at random, never past its neighbors (e.g. 10m)")
+	fs.StringVar(&opts.seed, "seed", "", "seed for RR, --randomize and --jitter to make runs reproducible")
+	fs.StringVar(&opts.coarsen, "coarsen", "", "round every date down to a granularity and drop the timezone offset (e.g. 1d, 1h)")
+	fs.StringVar(&opts.tz, "tz", "", "rewrite every date in this timezone (UTC, local or an IANA name)")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "print the resolved dates instead of rewriting history")
+	fs.StringVar(&opts.round, "round", "", "round resolved times to a granularity (e.g. 5m nearest, -5m down, +5m up)")
+	fs.BoolVar(&opts.stripSeconds, "strip-seconds", false, "truncate resolved times to whole minutes")
+	fs.StringVar(&opts.minGap, "min-gap", "", "push commits forward so consecutive commits are at least this far apart (e.g. 3m)")
+	fs.BoolVar(&opts.unique, "unique", false, "guarantee no two consecutive commits share a timestamp")
+	fs.StringVar(&opts.author, "author", "", "only retime commits whose author matches this pattern")
+	fs.StringVar(&opts.grep, "grep", "", "only retime commits whose message matches this pattern")
+	fs.StringVar(&opts.since, "since", "", "only retime commits more recent than this date")
+	fs.StringVar(&opts.until, "until", "", "only retime commits older than this date")
+	fs.BoolVar(&opts.hideUnmatched, "hide-unmatched", false, "leave commits that do not match the filters out of the todo instead of showing them as comments")
+	fs.StringVar(&opts.branch, "branch", "", "retime this branch instead of HEAD, without checking it out")
+	fs.StringVar(&opts.outputBranch, "output-branch", "", "write the retimed commits to this new branch and leave the original untouched")
+	fs.BoolVar(&opts.forcePublished, "force-rewrite-published", false, "allow rewriting commits that are already on a remote or protected branch")
+	fs.BoolVar(&opts.autostash, "autostash", false, "stash uncommitted changes before retiming and restore them afterwards")
+	fs.BoolVar(&opts.updateRefs, "update-refs", false, "move other local branches that point at rewritten commits")
+	fs.BoolVar(&opts.updateTags, "update-tags", false, "move tags that point at rewritten commits, recreating annotated tags")
+	fs.BoolVar(&opts.retimeTags, "retime-tag-dates", false, "with --update-tags, shift each annotated tag's date along with its commit")
+	fs.BoolVar(&opts.audit, "audit", false, "record each commit's original dates and who retimed it in refs/notes/retime")
+	fs.BoolVar(&opts.restore, "restore", false, "open the todo pre-filled with the dates commits had before they were retimed")
+	fs.Var(&opts.restoreCommitter, "restore-committer-dates", "set committer dates back to those before an ordinary rebase (optionally =<orig-head>, default ORIG_HEAD)")
+	fs.StringVar(&opts.unmatched, "unmatched", "keep", "with --restore-committer-dates, what to do with commits that have no pre-rebase version: keep, author-date or fail")
+	fs.BoolVar(&opts.printMap, "print-map", false, "print the old->new commit map to stdout after the rewrite")
+	fs.BoolVar(&opts.noAutoAbort, "no-auto-abort", false, "leave a failed rebase stopped to resolve it, then run --continue or --abort")
+	fs.BoolVar(&opts.runHooks, "run-hooks", false, "run the repository's commit hooks for every rewritten commit")
+	fs.BoolVar(&opts.noVerify, "no-verify", false, "skip commit hooks during the rewrite (default)")
 	fs.BoolVar(&opts.interactive, "i", false, "interactive mode (default, accepted for compatibility)")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: git retime [options] <revision>\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: git retime [options] [<revision>|<base>..<tip>] [-- <pathspec>...]\n\n")
 		fmt.Fprintf(os.Stderr, "Interactively edit commit timestamps.\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  git retime                     Open editor for commits not pushed upstream yet\n")
 		fmt.Fprintf(os.Stderr, "  git retime HEAD~5              Open editor for the last 5 commits\n")
 		fmt.Fprintf(os.Stderr, "  git retime abc1234             Retime from abc1234 to HEAD\n")
 		fmt.Fprintf(os.Stderr, "  git retime HEAD~3 --shift +2h  Shift last 3 commits by 2 hours\n")
 		fmt.Fprintf(os.Stderr, "  git retime HEAD~5 --randomize 09:00-17:00\n")
+		fmt.Fprintf(os.Stderr, "  git retime HEAD~20 --scale 0.5  Halve the gaps between the last 20 commits\n")
+		fmt.Fprintf(os.Stderr, "  git retime main --author alice  Retime only alice's commits since main\n")
+		fmt.Fprintf(os.Stderr, "  git retime main..topic --shift +1h  Retime branch topic without checking it out\n")
+		fmt.Fprintf(os.Stderr, "  git retime tags 'v1.*'          Edit the dates of annotated tags\n")
+		fmt.Fprintf(os.Stderr, "  git retime --continue           Reopen an unfinished todo or finish a stopped rebase\n")
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		fs.PrintDefaults()
 	}
@@ -59,50 +311,380 @@ func Run(args []string) error {
 	// Combine any remaining args from flag parsing with our positional args.
 	positional = append(positional, fs.Args()...)
 
-	if len(positional) < 1 {
-		fs.Usage()
-		return errors.New("missing revision argument")
+	var arg string
+	if len(positional) > 0 {
+		arg = positional[0]
+	}
+
+	revision, tipRev, err := splitRange(arg, opts.branch)
+	if err != nil {
+		return err
+	}
+
+	// Without a revision, retime the commits that are not upstream yet.
+	defaulted := revision == ""
+	if defaulted {
+		var from string
+		revision, from, err = git.DefaultBase(ctx, tipRev)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "no revision given, retiming commits not on %s\n", from)
+	}
+
+	if opts.outputBranch != "" && (opts.updateRefs || opts.updateTags) {
+		return errors.New("--update-refs and --update-tags cannot be combined with --output-branch, which leaves the original commits in place")
+	}
+	if opts.runHooks && opts.noVerify {
+		return errors.New("--run-hooks and --no-verify cannot be combined")
+	}
+	if opts.retimeTags && !opts.updateTags {
+		return errors.New("--retime-tag-dates requires --update-tags")
 	}
 
-	revision := positional[0]
+	target, err := git.ResolveTarget(ctx, tipRev, opts.outputBranch)
+	if err != nil {
+		return err
+	}
+	if resume != nil && target.Tip != resume.tip {
+		return fmt.Errorf("%s has moved since the session was saved (was %.7s, now %.7s)\nhint: start a new retime instead", tipRev, resume.tip, target.Tip)
+	}
 
-	commits, base, needsRoot, err := git.FetchCommits(revision)
+	commits, base, needsRoot, err := git.FetchCommits(ctx, revision, target.Tip, defaulted)
 	if err != nil {
 		return err
 	}
+	sc := scope{
+		commits:     commits,
+		base:        base,
+		needsRoot:   needsRoot,
+		target:      target,
+		autostash:   opts.autostash,
+		updateRefs:  opts.updateRefs,
+		updateTags:  opts.updateTags,
+		retimeTags:  opts.retimeTags,
+		audit:       opts.audit,
+		printMap:    opts.printMap,
+		runHooks:    opts.runHooks,
+		noAutoAbort: opts.noAutoAbort,
+	}
 
 	if len(commits) == 0 {
+		if defaulted {
+			return errors.New("nothing to retime: all commits are already upstream")
+		}
 		return errors.New("no commits in the specified range")
 	}
 
+	filter := git.Filter{
+		Author: opts.author,
+		Grep:   opts.grep,
+		Since:  opts.since,
+		Until:  opts.until,
+		Paths:  paths,
+	}
+	if err := git.MarkContext(ctx, commits, base, target.Tip, filter); err != nil {
+		return err
+	}
+
+	var originals map[string]git.OriginalDates
+	if opts.restore {
+		if opts.shift != "" || opts.randomize != "" || opts.scale != "" || opts.fitInto != "" {
+			return errors.New("--restore cannot be combined with --shift, --randomize, --scale or --fit-into")
+		}
+		originals, err = findOriginals(ctx, sc)
+		if err != nil {
+			return err
+		}
+		// Both dates are restored, so show both columns.
+		opts.splitDates = true
+	}
+	selected := selectedCommits(commits)
+	if len(selected) == 0 {
+		return errors.New("no commits in the specified range match the filters")
+	}
+
+	if !opts.dryRun {
+		if err := git.Preflight(ctx, commits, target, opts.autostash); err != nil {
+			return err
+		}
+	}
+
+	// Writing to a new branch or only previewing leaves published history
+	// alone, so there is nothing to protect.
+	if !opts.forcePublished && !opts.dryRun && !target.Create {
+		if err := checkPublished(ctx, sc); err != nil {
+			return err
+		}
+	}
+
+	adj, err := parseAdjustments(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.seed != "" {
+		seed, err := strconv.ParseUint(opts.seed, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --seed value: expected a non-negative integer, got %q", opts.seed)
+		}
+		timestamp.Seed(seed)
+	}
+
 	now := time.Now()
 
-	if opts.shift != "" {
-		return runShift(commits, base, needsRoot, opts.shift, opts.splitDates, now)
+	var tsCommits []timestamp.Commit
+	switch {
+	case opts.restore:
+		sess, err := openSession(ctx, args, target.Tip, resume)
+		if err != nil {
+			return err
+		}
+		return runInteractive(ctx, sc, opts, adj, now, originals, sess)
+	case opts.restoreCommitter.set:
+		tsCommits, err = planRestoreCommitterDates(ctx, sc, selected, opts.restoreCommitter.value, opts.unmatched)
+	case opts.shift != "":
+		tsCommits, err = planShift(selected, opts.shift)
+	case opts.randomize != "":
+		tsCommits, err = planRandomize(selected, opts.randomize, opts.randomizeAllowParadox)
+	case opts.scale != "":
+		tsCommits, err = planScale(selected, opts.scale, opts.scaleAnchor)
+	case opts.fitInto != "":
+		tsCommits, err = planFitInto(selected, opts.fitInto)
+	case opts.jitter != "", opts.coarsen != "", opts.tz != "":
+		// Whole-range policies apply to the existing history without an editor.
+		tsCommits = unchangedPlan(selected)
+	default:
+		sess, err := openSession(ctx, args, target.Tip, resume)
+		if err != nil {
+			return err
+		}
+		return runInteractive(ctx, sc, opts, adj, now, nil, sess)
 	}
-	if opts.randomize != "" {
-		return runRandomize(commits, base, needsRoot, opts.randomize, opts.splitDates, opts.randomizeAllowParadox, now)
+	if err != nil {
+		return err
 	}
 
-	return runInteractive(commits, base, needsRoot, opts.splitDates, now)
+	tsCommits = adj.apply(commits, tsCommits)
+	if opts.dryRun {
+		printPlan(os.Stdout, tsCommits)
+		return nil
+	}
+	var sess *session
+	if opts.noAutoAbort {
+		// A stopped rebase is continued or aborted through a session.
+		if sess, err = openSession(ctx, args, target.Tip, resume); err != nil {
+			return err
+		}
+	}
+	if err := executeRebase(ctx, tsCommits, sc, sess); err != nil {
+		if sess != nil && errors.Is(err, context.Canceled) {
+			// The interrupted rewrite was rolled back; there is nothing
+			// to continue.
+			sess.remove()
+		}
+		return err
+	}
+	if sess != nil {
+		return sess.remove()
+	}
+	return nil
 }
 
-func runInteractive(commits []git.CommitInfo, base string, needsRoot, splitDates bool, now time.Time) error {
-	editor, err := git.GetEditor()
+// checkPublished refuses to rewrite commits that others may already have.
+func checkPublished(ctx context.Context, sc scope) error {
+	published, err := git.FindPublished(ctx, sc.commits, sc.base, sc.target.Tip, git.ProtectedRefPatterns(ctx))
 	if err != nil {
 		return err
 	}
+	if len(published) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("refusing to rewrite published commits:")
+	for _, p := range published {
+		fmt.Fprintf(&b, "\n  %s %s (%s)", p.Commit.ShortHash, p.Commit.Subject, strings.Join(p.Refs, ", "))
+	}
+	b.WriteString("\nhint: use --output-branch to write to a new branch, or pass --force-rewrite-published")
+	return errors.New(b.String())
+}
+
+// splitRange splits "A..B" into base A and tip B. A plain revision is the
+// base and the tip is --branch, or HEAD when no branch is given. An empty
+// arg yields an empty revision, to be filled in from the upstream.
+func splitRange(arg, branch string) (revision, tip string, err error) {
+	if strings.Contains(arg, "...") {
+		return "", "", fmt.Errorf("symmetric difference %q is not supported; use <base>..<tip>", arg)
+	}
+
+	revision, tip, isRange := strings.Cut(arg, "..")
+	if !isRange {
+		tip = branch
+	} else if branch != "" {
+		return "", "", errors.New("--branch cannot be combined with a <base>..<tip> range")
+	}
+	if isRange && revision == "" {
+		return "", "", fmt.Errorf("missing base revision in %q", arg)
+	}
+	if tip == "" {
+		tip = "HEAD"
+	}
+	return revision, tip, nil
+}
+
+// findOriginals looks up the pre-retime dates of the selected commits for
+// --restore. Commits without any are turned into context so they keep their
+// dates.
+func findOriginals(ctx context.Context, sc scope) (map[string]git.OriginalDates, error) {
+	originals, err := git.FindOriginalDates(ctx, selectedCommits(sc.commits), sc.target.Tip, reflogs(sc.target))
+	if err != nil {
+		return nil, err
+	}
+	if len(originals) == 0 {
+		return nil, errors.New("no original dates found for the commits in the range\nhint: originals come from --audit notes or from old commits still in the reflog")
+	}
+
+	restored := 0
+	for i := range sc.commits {
+		c := &sc.commits[i]
+		if c.Context {
+			continue
+		}
+		if _, ok := originals[c.Hash]; ok {
+			restored++
+		} else {
+			c.Context = true
+		}
+	}
+	fmt.Fprintf(os.Stderr, "found original dates for %d commit(s)\n", restored)
+	return originals, nil
+}
+
+// reflogs names the refs whose reflogs may hold older versions of the
+// commits being retimed.
+func reflogs(target git.Target) []string {
+	refs := []string{"HEAD"}
+	if !target.InPlace() && !target.Create {
+		refs = append(refs, target.Ref)
+	}
+	return refs
+}
 
-	todoContent := todo.Generate(commits, base, splitDates)
+// planRestoreCommitterDates keeps author dates and sets each committer date
+// back to that of the commit's version from before an ordinary rebase.
+// unmatched decides what happens to commits without one: "keep" their
+// committer date, use their "author-date", or "fail".
+func planRestoreCommitterDates(ctx context.Context, sc scope, commits []git.CommitInfo, origHead, unmatched string) ([]timestamp.Commit, error) {
+	switch unmatched {
+	case "keep", "author-date", "fail":
+	default:
+		return nil, fmt.Errorf("invalid --unmatched value %q: expected keep, author-date or fail", unmatched)
+	}
 
-	todoPath := filepath.Join(gitDir(), "git-retime-todo")
+	if origHead == "" {
+		// Without ORIG_HEAD only the reflogs are searched.
+		if _, err := git.ResolveRevision(ctx, "ORIG_HEAD"); err == nil {
+			origHead = "ORIG_HEAD"
+		}
+	}
+	found, err := git.FindPreRebaseDates(ctx, commits, sc.target.Tip, origHead, reflogs(sc.target))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := unchangedPlan(commits)
+	var missing []string
+	for i, c := range commits {
+		if d, ok := found[c.Hash]; ok {
+			plan[i].ResolvedCommitDate = d.CommitDate
+			continue
+		}
+		missing = append(missing, c.ShortHash)
+		if unmatched == "author-date" {
+			plan[i].ResolvedCommitDate = c.AuthorDate
+		}
+	}
+
+	if len(missing) > 0 && unmatched == "fail" {
+		return nil, fmt.Errorf("no pre-rebase version found for %s\nhint: pass --unmatched keep or --unmatched author-date", strings.Join(missing, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "restoring committer dates of %d of %d commit(s)\n", len(commits)-len(missing), len(commits))
+	return plan, nil
+}
+
+// withOriginalDates returns a copy of commits showing the original dates
+// where they are known, so the todo opens pre-filled with them.
+func withOriginalDates(commits []git.CommitInfo, originals map[string]git.OriginalDates) []git.CommitInfo {
+	shown := make([]git.CommitInfo, len(commits))
+	for i, c := range commits {
+		if d, ok := originals[c.Hash]; ok {
+			c.AuthorDate, c.CommitDate = d.AuthorDate, d.CommitDate
+		}
+		shown[i] = c
+	}
+	return shown
+}
+
+// restoreZones gives dates that resolved to exactly the original instant
+// the original timezone offset back; the todo only shows local time.
+func restoreZones(tsCommits []timestamp.Commit, originals map[string]git.OriginalDates) {
+	for i := range tsCommits {
+		c := &tsCommits[i]
+		d, ok := originals[c.Hash]
+		if !ok {
+			continue
+		}
+		if c.ResolvedAuthorDate.Equal(d.AuthorDate) {
+			c.ResolvedAuthorDate = d.AuthorDate
+		}
+		if c.ResolvedCommitDate.Equal(d.CommitDate) {
+			c.ResolvedCommitDate = d.CommitDate
+		}
+	}
+}
+
+// runInteractive opens the todo in the editor. With originals (--restore),
+// the todo is pre-filled with the commits' original dates. The todo lives in
+// sess and is kept until the retime succeeds or is aborted, so it can be
+// reopened with --continue after a failure.
+func runInteractive(ctx context.Context, sc scope, opts options, adj adjustments, now time.Time, originals map[string]git.OriginalDates, sess *session) (err error) {
+	commits, base := sc.commits, sc.base
+	splitDates := opts.splitDates
+
+	editor, err := git.GetEditor(ctx)
+	if err != nil {
+		return err
+	}
+
+	selected := selectedCommits(commits)
+	shown := commits
+	if opts.hideUnmatched {
+		shown = selected
+	}
+	if originals != nil {
+		shown = withOriginalDates(shown, originals)
+	}
+	todoContent := sess.todo
+	if todoContent == "" {
+		todoContent = todo.Generate(shown, base, splitDates)
+	}
+	todoPath := sess.todoPath()
+
+	opened := false
+	defer func() {
+		var stopped *git.RebaseStoppedError
+		if err != nil && opened && !errors.As(err, &stopped) {
+			fmt.Fprintln(os.Stderr, "hint: your edits are saved; run \"git retime --continue\" to reopen them")
+		}
+	}()
 
 	for {
-		if err := os.WriteFile(todoPath, []byte(todoContent), 0644); err != nil {
-			return fmt.Errorf("writing todo file: %w", err)
+		if err := sess.writeTodo(todoContent); err != nil {
+			return err
 		}
-		defer os.Remove(todoPath)
 
+		opened = true
 		if err := git.OpenEditor(editor, todoPath); err != nil {
 			return fmt.Errorf("editor failed: %w", err)
 		}
@@ -116,7 +698,7 @@ func runInteractive(commits []git.CommitInfo, base string, needsRoot, splitDates
 
 		if todo.IsAbort(content) {
 			fmt.Fprintln(os.Stderr, "retime aborted")
-			return nil
+			return sess.remove()
 		}
 
 		entries, err := todo.Parse(content, splitDates)
@@ -124,11 +706,11 @@ func runInteractive(commits []git.CommitInfo, base string, needsRoot, splitDates
 			return err
 		}
 
-		if err := todo.ValidateStructure(entries, commits); err != nil {
+		if err := todo.ValidateStructure(entries, selected); err != nil {
 			return err
 		}
 
-		tsCommits, err := todo.ToCommits(entries, commits, splitDates)
+		tsCommits, err := todo.ToCommits(entries, selected, splitDates)
 		if err != nil {
 			return err
 		}
@@ -136,6 +718,8 @@ func runInteractive(commits []git.CommitInfo, base string, needsRoot, splitDates
 		if err := timestamp.ResolveAll(tsCommits, now, splitDates); err != nil {
 			return err
 		}
+		restoreZones(tsCommits, originals)
+		tsCommits = adj.apply(commits, tsCommits)
 
 		paradoxes := checkParadoxes(tsCommits)
 		if len(paradoxes) > 0 {
@@ -156,61 +740,143 @@ func runInteractive(commits []git.CommitInfo, base string, needsRoot, splitDates
 			}
 		}
 
-		return executeRebase(tsCommits, base, needsRoot)
+		if opts.dryRun {
+			printPlan(os.Stdout, tsCommits)
+			return sess.remove()
+		}
+		if err := executeRebase(ctx, tsCommits, sc, sess); err != nil {
+			return err
+		}
+		return sess.remove()
 	}
 }
 
-func runShift(commits []git.CommitInfo, base string, needsRoot bool, shiftExpr string, splitDates bool, now time.Time) error {
+// openSession returns the session being resumed, or starts a new one.
+func openSession(ctx context.Context, args []string, tip string, resume *session) (*session, error) {
+	if resume != nil {
+		return resume, nil
+	}
+	return newSession(ctx, args, tip)
+}
+
+func planShift(commits []git.CommitInfo, shiftExpr string) ([]timestamp.Commit, error) {
 	shift, err := timestamp.ParseShift(shiftExpr)
 	if err != nil {
-		return fmt.Errorf("invalid --shift value: %w", err)
+		return nil, fmt.Errorf("invalid --shift value: %w", err)
 	}
 
-	tsCommits := make([]timestamp.Commit, len(commits))
-	for i, c := range commits {
-		tsCommits[i] = timestamp.Commit{
-			Hash:               c.Hash,
-			OrigAuthorDate:     c.AuthorDate,
-			OrigCommitDate:     c.CommitDate,
-			Subject:            c.Subject,
-			Body:               c.Body,
-			NewSubject:         c.Subject,
-			ResolvedAuthorDate: c.AuthorDate.Add(shift),
-			ResolvedCommitDate: c.CommitDate.Add(shift),
+	tsCommits := unchangedPlan(commits)
+	for i := range tsCommits {
+		tsCommits[i].ResolvedAuthorDate = tsCommits[i].OrigAuthorDate.Add(shift)
+		tsCommits[i].ResolvedCommitDate = tsCommits[i].OrigCommitDate.Add(shift)
+	}
+
+	return tsCommits, nil
+}
+
+func planScale(commits []git.CommitInfo, factorExpr, anchorExpr string) ([]timestamp.Commit, error) {
+	factor, err := strconv.ParseFloat(factorExpr, 64)
+	if err != nil || factor <= 0 {
+		return nil, fmt.Errorf("invalid --scale value: expected a positive number, got %q", factorExpr)
+	}
+
+	tsCommits := unchangedPlan(commits)
+
+	var anchor time.Time
+	switch strings.ToLower(anchorExpr) {
+	case "", "first":
+		anchor = tsCommits[0].OrigAuthorDate
+	case "last":
+		anchor = tsCommits[len(tsCommits)-1].OrigAuthorDate
+	default:
+		anchor, err = timestamp.ParseLocal(anchorExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --scale-anchor value: expected first, last or a timestamp: %w", err)
 		}
 	}
 
-	return executeRebase(tsCommits, base, needsRoot)
+	timestamp.Scale(tsCommits, anchor, factor)
+	return tsCommits, nil
+}
+
+func planFitInto(commits []git.CommitInfo, spanExpr string) ([]timestamp.Commit, error) {
+	start, end, err := timestamp.ParseSpan(spanExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --fit-into value: %w", err)
+	}
+
+	tsCommits := unchangedPlan(commits)
+	timestamp.FitInto(tsCommits, start, end)
+	return tsCommits, nil
 }
 
-func runRandomize(commits []git.CommitInfo, base string, needsRoot bool, rangeExpr string, splitDates, allowParadox bool, now time.Time) error {
+func planRandomize(commits []git.CommitInfo, rangeExpr string, allowParadox bool) ([]timestamp.Commit, error) {
 	parts := strings.SplitN(rangeExpr, "-", 2)
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid --randomize range: expected HH:MM-HH:MM, got %q", rangeExpr)
+		return nil, fmt.Errorf("invalid --randomize range: expected HH:MM-HH:MM, got %q", rangeExpr)
 	}
 
 	startTime, err := parseTimeOfDay(parts[0])
 	if err != nil {
-		return fmt.Errorf("invalid randomize start: %w", err)
+		return nil, fmt.Errorf("invalid randomize start: %w", err)
 	}
 	endTime, err := parseTimeOfDay(parts[1])
 	if err != nil {
-		return fmt.Errorf("invalid randomize end: %w", err)
+		return nil, fmt.Errorf("invalid randomize end: %w", err)
 	}
 
 	if endTime <= startTime {
-		return fmt.Errorf("randomize end time must be after start time")
+		return nil, fmt.Errorf("randomize end time must be after start time")
 	}
 
 	times := make([]time.Time, len(commits))
 	for i, c := range commits {
-		times[i] = randomizeTime(c.AuthorDate, startTime, endTime)
+		times[i] = timestamp.RandomTimeOfDay(c.AuthorDate, startTime, endTime)
 	}
 
 	if !allowParadox {
 		sortTimesWithinDays(commits, times)
 	}
 
+	tsCommits := unchangedPlan(commits)
+	for i := range tsCommits {
+		tsCommits[i].ResolvedAuthorDate = times[i]
+		tsCommits[i].ResolvedCommitDate = times[i]
+	}
+
+	return tsCommits, nil
+}
+
+// selectedCommits returns the commits that are not Context.
+func selectedCommits(commits []git.CommitInfo) []git.CommitInfo {
+	var selected []git.CommitInfo
+	for _, c := range commits {
+		if !c.Context {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// mergePlan expands a plan for the selected commits into one covering the
+// whole range. Context commits keep their original dates; they still need
+// an entry because replaying them onto a rewritten parent would otherwise
+// reset their committer date.
+func mergePlan(commits []git.CommitInfo, selectedPlan []timestamp.Commit) []timestamp.Commit {
+	full := unchangedPlan(commits)
+	next := 0
+	for i, c := range commits {
+		if !c.Context {
+			full[i] = selectedPlan[next]
+			next++
+		}
+	}
+	return full
+}
+
+// unchangedPlan converts commits into a plan that keeps every original
+// timestamp and message. Bulk modes start from it and adjust the dates.
+func unchangedPlan(commits []git.CommitInfo) []timestamp.Commit {
 	tsCommits := make([]timestamp.Commit, len(commits))
 	for i, c := range commits {
 		tsCommits[i] = timestamp.Commit{
@@ -220,12 +886,11 @@ func runRandomize(commits []git.CommitInfo, base string, needsRoot bool, rangeEx
 			Subject:            c.Subject,
 			Body:               c.Body,
 			NewSubject:         c.Subject,
-			ResolvedAuthorDate: times[i],
-			ResolvedCommitDate: times[i],
+			ResolvedAuthorDate: c.AuthorDate,
+			ResolvedCommitDate: c.CommitDate,
 		}
 	}
-
-	return executeRebase(tsCommits, base, needsRoot)
+	return tsCommits
 }
 
 // sortTimesWithinDays sorts the randomized times in-place, but only within
@@ -263,22 +928,219 @@ func sortTimesWithinDays(commits []git.CommitInfo, times []time.Time) {
 	}
 }
 
-func executeRebase(tsCommits []timestamp.Commit, base string, needsRoot bool) error {
-	compiled := compile.Compile(tsCommits)
+func executeRebase(ctx context.Context, tsCommits []timestamp.Commit, sc scope, sess *session) error {
+	// With --no-auto-abort the files the rebase reads live in the session,
+	// so a stopped rebase can still use them when it is continued.
+	var work string
+	if sc.noAutoAbort {
+		work = sess.rebaseDir()
+		if err := os.MkdirAll(work, 0755); err != nil {
+			return fmt.Errorf("creating rebase directory: %w", err)
+		}
+	} else {
+		dir, err := os.MkdirTemp("", "git-retime-*")
+		if err != nil {
+			return fmt.Errorf("creating temp directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		work = dir
+	}
 
-	tmpFile, err := os.CreateTemp("", "git-retime-rebase-*.todo")
-	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+	mapFile := filepath.Join(work, "map")
+	if err := os.WriteFile(mapFile, nil, 0644); err != nil {
+		return fmt.Errorf("creating commit map: %w", err)
+	}
+	msgDir := filepath.Join(work, "messages")
+	if err := os.MkdirAll(msgDir, 0755); err != nil {
+		return fmt.Errorf("creating message directory: %w", err)
+	}
+	for name, msg := range compile.MessageFiles(tsCommits) {
+		if err := os.WriteFile(filepath.Join(msgDir, name), []byte(msg), 0644); err != nil {
+			return fmt.Errorf("writing commit message: %w", err)
+		}
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err := tmpFile.WriteString(compiled); err != nil {
-		tmpFile.Close()
+	compiled := compile.Compile(tsCommits, compile.Options{
+		MapFile:    mapFile,
+		RunHooks:   sc.runHooks,
+		MessageDir: msgDir,
+	})
+	todoFile := filepath.Join(work, "todo")
+	if err := os.WriteFile(todoFile, []byte(compiled), 0644); err != nil {
 		return fmt.Errorf("writing compiled todo: %w", err)
 	}
-	tmpFile.Close()
 
-	return git.ExecuteRebase(tmpFile.Name(), base, needsRoot)
+	// The signals stay trapped until the bookkeeping below is done too, so
+	// it is never cut short once the rebase has succeeded.
+	rebaseCtx, stop := trapSignals(ctx)
+	defer stop()
+	err := git.ExecuteRebase(rebaseCtx, todoFile, git.RebaseOptions{
+		Base:        sc.base,
+		NeedsRoot:   sc.needsRoot,
+		Target:      sc.target,
+		Autostash:   sc.autostash,
+		RunHooks:    sc.runHooks,
+		NoAutoAbort: sc.noAutoAbort,
+	})
+	var stopped *git.RebaseStoppedError
+	if errors.As(err, &stopped) {
+		if err := sess.saveStopped(stoppedRewrite{
+			Commits:    tsCommits,
+			Target:     sc.target,
+			Dir:        stopped.Dir,
+			UpdateRefs: sc.updateRefs,
+			UpdateTags: sc.updateTags,
+			RetimeTags: sc.retimeTags,
+			Audit:      sc.audit,
+			PrintMap:   sc.printMap,
+			RunHooks:   sc.runHooks,
+		}); err != nil {
+			return err
+		}
+		printStoppedHint(stopped.Dir)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return finishRewrite(ctx, tsCommits, sc, mapFile)
+}
+
+// trapSignals returns a context that Ctrl-C or SIGTERM cancels. Until stop
+// is called those signals no longer kill git-retime, so a rewrite in
+// progress gets to roll back or finish cleanly instead.
+func trapSignals(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+// finishRewrite carries the rewrite over to notes, refs, the commit map and
+// the post-rewrite hook once the rebase is done. mapFile is the map the
+// compiled todo's exec lines wrote.
+func finishRewrite(ctx context.Context, tsCommits []timestamp.Commit, sc scope, mapFile string) error {
+	mapping, err := git.ReadCommitMap(mapFile)
+	if err != nil {
+		return err
+	}
+	if err := git.CopyNotes(ctx, mapping); err != nil {
+		return err
+	}
+	if sc.audit {
+		if err := git.WriteAuditNotes(ctx, auditEntries(tsCommits, mapping), time.Now()); err != nil {
+			return err
+		}
+	}
+	if err := updateRefs(ctx, tsCommits, mapping, sc); err != nil {
+		return err
+	}
+
+	// Keep the map in range order, like git's own rewritten list.
+	var commitMap strings.Builder
+	for _, c := range tsCommits {
+		if newHash, ok := mapping[c.Hash]; ok {
+			fmt.Fprintf(&commitMap, "%s %s\n", c.Hash, newHash)
+		}
+	}
+	mapPath, err := git.SaveCommitMap(ctx, commitMap.String())
+	if err != nil {
+		return err
+	}
+	if sc.printMap {
+		fmt.Print(commitMap.String())
+	}
+	if sc.runHooks {
+		// git already ran post-rewrite for every amend and the rebase.
+		return nil
+	}
+	return git.RunPostRewriteHook(ctx, mapPath)
+}
+
+// printStoppedHint tells the user how to finish a rebase left stopped by
+// --no-auto-abort.
+func printStoppedHint(dir string) {
+	if dir != "" {
+		// The session lives in this worktree's git directory, not in the
+		// temporary one's.
+		fmt.Fprintf(os.Stderr, "hint: the rebase is stopped in %s\n", dir)
+		fmt.Fprintln(os.Stderr, "hint: resolve the problem there, then run \"git retime --continue\" back in this worktree")
+	} else {
+		fmt.Fprintln(os.Stderr, "hint: resolve the problem, then run \"git retime --continue\"")
+	}
+	fmt.Fprintln(os.Stderr, "hint: or run \"git retime --abort\" to undo the retime")
+}
+
+// auditEntries lists the rewritten commits whose dates changed.
+func auditEntries(tsCommits []timestamp.Commit, mapping map[string]string) []git.AuditEntry {
+	var entries []git.AuditEntry
+	for _, c := range tsCommits {
+		newHash, ok := mapping[c.Hash]
+		if !ok {
+			continue
+		}
+		if c.ResolvedAuthorDate.Equal(c.OrigAuthorDate) && c.ResolvedCommitDate.Equal(c.OrigCommitDate) {
+			continue
+		}
+		entries = append(entries, git.AuditEntry{
+			Commit:         newHash,
+			OrigAuthorDate: c.OrigAuthorDate,
+			OrigCommitDate: c.OrigCommitDate,
+		})
+	}
+	return entries
+}
+
+// updateRefs moves the branches and tags that pointed at rewritten commits,
+// as requested by --update-refs and --update-tags.
+func updateRefs(ctx context.Context, tsCommits []timestamp.Commit, mapping map[string]string, sc scope) error {
+	if sc.updateRefs {
+		branches, err := git.UpdateBranches(ctx, mapping)
+		for _, b := range branches {
+			fmt.Fprintf(os.Stderr, "updated branch %s\n", b)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if sc.updateTags {
+		shifts := make(map[string]time.Duration)
+		if sc.retimeTags {
+			for _, c := range tsCommits {
+				shifts[c.Hash] = c.ResolvedCommitDate.Sub(c.OrigCommitDate)
+			}
+		}
+		tags, err := git.UpdateTags(ctx, mapping, shifts)
+		for _, t := range tags {
+			if t.DroppedSignature {
+				fmt.Fprintf(os.Stderr, "updated tag %s (warning: signature dropped, re-sign it with git tag -s -f)\n", t.Name)
+			} else {
+				fmt.Fprintf(os.Stderr, "updated tag %s\n", t.Name)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan writes each commit's original and resolved dates with their
+// timezone offsets. The committer date is listed separately only when it
+// differs from the author date.
+func printPlan(w io.Writer, commits []timestamp.Commit) {
+	for _, c := range commits {
+		fmt.Fprintf(w, "%s  %s -> %s  %s\n",
+			c.Hash[:minInt(7, len(c.Hash))],
+			timestamp.FormatGit(c.OrigAuthorDate),
+			timestamp.FormatGit(c.ResolvedAuthorDate),
+			c.NewSubject,
+		)
+		if !c.OrigCommitDate.Equal(c.OrigAuthorDate) || !c.ResolvedCommitDate.Equal(c.ResolvedAuthorDate) {
+			fmt.Fprintf(w, "         committer %s -> %s\n",
+				timestamp.FormatGit(c.OrigCommitDate),
+				timestamp.FormatGit(c.ResolvedCommitDate),
+			)
+		}
+	}
 }
 
 func checkParadoxes(commits []timestamp.Commit) []string {
@@ -310,14 +1172,6 @@ func promptYesNo(question string) (bool, error) {
 	return answer == "y" || answer == "yes", nil
 }
 
-func gitDir() string {
-	// Rely on GIT_DIR or default to .git in current directory.
-	if dir := os.Getenv("GIT_DIR"); dir != "" {
-		return dir
-	}
-	return ".git"
-}
-
 // parseTimeOfDay parses "HH:MM" into total seconds since midnight.
 func parseTimeOfDay(s string) (int, error) {
 	s = strings.TrimSpace(s)
@@ -338,18 +1192,6 @@ func parseTimeOfDay(s string) (int, error) {
 	return h*3600 + m*60, nil
 }
 
-func randomizeTime(original time.Time, startSec, endSec int) time.Time {
-	y, mo, d := original.Date()
-	loc := original.Location()
-
-	randomSec := startSec + rand.IntN(endSec-startSec)
-	h := randomSec / 3600
-	m := (randomSec % 3600) / 60
-	sec := randomSec % 60
-
-	return time.Date(y, mo, d, h, m, sec, 0, loc)
-}
-
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -359,15 +1201,36 @@ func minInt(a, b int) int {
 
 // reorderArgs separates flag arguments from positional arguments so that
 // flags can appear anywhere in the command line. Flags that take values
-// (--shift, --randomize) consume the next argument as their value.
-func reorderArgs(args []string) (flagArgs, positional []string) {
+// (--shift, --randomize, ...) consume the next argument as their value.
+// Everything after a "--" separator is returned as pathspecs.
+func reorderArgs(args []string) (flagArgs, positional, paths []string) {
 	valueFlagSet := map[string]bool{
 		"--shift": true, "-shift": true,
 		"--randomize": true, "-randomize": true,
+		"--round": true, "-round": true,
+		"--min-gap": true, "-min-gap": true,
+		"--scale": true, "-scale": true,
+		"--scale-anchor": true, "-scale-anchor": true,
+		"--fit-into": true, "-fit-into": true,
+		"--jitter": true, "-jitter": true,
+		"--seed": true, "-seed": true,
+		"--coarsen": true, "-coarsen": true,
+		"--tz": true, "-tz": true,
+		"--author": true, "-author": true,
+		"--grep": true, "-grep": true,
+		"--since": true, "-since": true,
+		"--until": true, "-until": true,
+		"--branch": true, "-branch": true,
+		"--output-branch": true, "-output-branch": true,
+		"--unmatched": true, "-unmatched": true,
 	}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
+		if arg == "--" {
+			paths = append(paths, args[i+1:]...)
+			break
+		}
 		if strings.HasPrefix(arg, "-") {
 			flagArgs = append(flagArgs, arg)
 			if valueFlagSet[arg] && i+1 < len(args) {
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erfnzdeh/git-retime/internal/git"
+	"github.com/erfnzdeh/git-retime/internal/timestamp"
+)
+
+// session is an interactive retime saved under .git/retime/session/, so a
+// hand-edited todo survives an editor crash, a wrong answer at a prompt or
+// a failed rebase, and can be reopened with --continue.
+type session struct {
+	dir string
+	// args is the command line the session was started with.
+	args []string
+	// tip is the commit the range ended at when the session started.
+	tip string
+	// todo is the last edited todo, empty for a new session.
+	todo string
+	// stopped is the rewrite whose rebase --no-auto-abort left stopped.
+	stopped *stoppedRewrite
+}
+
+// stoppedRewrite is what finishing a stopped rebase needs from the run
+// that started it.
+type stoppedRewrite struct {
+	Commits []timestamp.Commit
+	Target  git.Target
+	// Dir is the worktree the rebase stopped in, "" for the current one.
+	Dir        string
+	UpdateRefs bool
+	UpdateTags bool
+	RetimeTags bool
+	Audit      bool
+	PrintMap   bool
+	RunHooks   bool
+}
+
+func sessionDir(ctx context.Context) (string, error) {
+	stateDir, err := git.StateDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "session"), nil
+}
+
+// newSession starts a session for args, replacing any previous one.
+func newSession(ctx context.Context, args []string, tip string) (*session, error) {
+	dir, err := sessionDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "rebase", "stopped")); err == nil {
+		return nil, errors.New("a retime is stopped in the middle of its rebase\nhint: run \"git retime --continue\" or \"git retime --abort\" first")
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("removing old session: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+	s := &session{dir: dir, args: args, tip: tip}
+	if err := writeFileAtomic(filepath.Join(dir, "args"), strings.Join(args, "\x00")); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "tip"), tip+"\n"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadSession reads the saved session.
+func loadSession(ctx context.Context) (*session, error) {
+	dir, err := sessionDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("no retime session in progress")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	tip, err := os.ReadFile(filepath.Join(dir, "tip"))
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	s := &session{dir: dir, tip: strings.TrimSpace(string(tip))}
+	if len(args) > 0 {
+		s.args = strings.Split(string(args), "\x00")
+	}
+	if data, err := os.ReadFile(s.todoPath()); err == nil {
+		s.todo = string(data)
+	}
+	data, err := os.ReadFile(s.stoppedPath())
+	if err == nil {
+		s.stopped = new(stoppedRewrite)
+		if err := json.Unmarshal(data, s.stopped); err != nil {
+			return nil, fmt.Errorf("reading stopped rebase: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading stopped rebase: %w", err)
+	}
+	return s, nil
+}
+
+// todoPath is the file the editor opens.
+func (s *session) todoPath() string {
+	return filepath.Join(s.dir, "todo")
+}
+
+// rebaseDir holds the compiled todo, messages and commit map of a rebase
+// run with --no-auto-abort.
+func (s *session) rebaseDir() string {
+	return filepath.Join(s.dir, "rebase")
+}
+
+func (s *session) stoppedPath() string {
+	return filepath.Join(s.rebaseDir(), "stopped")
+}
+
+// saveStopped records a rebase left stopped, for --continue and --abort.
+func (s *session) saveStopped(r stoppedRewrite) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("saving stopped rebase: %w", err)
+	}
+	return writeFileAtomic(s.stoppedPath(), string(data))
+}
+
+// writeTodo replaces the todo without ever leaving a half-written file.
+func (s *session) writeTodo(content string) error {
+	return writeFileAtomic(s.todoPath(), content)
+}
+
+// remove deletes the session once it has been carried out or abandoned.
+func (s *session) remove() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("removing session: %w", err)
+	}
+	return nil
+}
+
+// continueSession finishes a rebase left stopped by --no-auto-abort, or
+// otherwise reopens the saved todo against the same range, provided the
+// range's tip has not moved since.
+func continueSession(ctx context.Context) error {
+	s, err := loadSession(ctx)
+	if err != nil {
+		return err
+	}
+	if s.stopped == nil {
+		return run(ctx, s.args, s)
+	}
+
+	r := s.stopped
+	rebaseCtx, stop := trapSignals(ctx)
+	defer stop()
+	mapFile := filepath.Join(s.rebaseDir(), "map")
+	err = git.ContinueRebase(rebaseCtx, r.Dir, git.RebaseOptions{Target: r.Target, RunHooks: r.RunHooks}, func(head string) error {
+		return checkRewritten(r.Commits, mapFile, head)
+	})
+	var stopped *git.RebaseStoppedError
+	if errors.As(err, &stopped) {
+		printStoppedHint(r.Dir)
+	}
+	if err != nil {
+		return err
+	}
+	sc := scope{
+		target:     r.Target,
+		updateRefs: r.UpdateRefs,
+		updateTags: r.UpdateTags,
+		retimeTags: r.RetimeTags,
+		audit:      r.Audit,
+		printMap:   r.PrintMap,
+		runHooks:   r.RunHooks,
+	}
+	if err := finishRewrite(ctx, r.Commits, sc, mapFile); err != nil {
+		return err
+	}
+	return s.remove()
+}
+
+// checkRewritten makes sure a continued rebase really rewrote every planned
+// commit and ended on the last one, rather than having been aborted or
+// reset by hand.
+func checkRewritten(commits []timestamp.Commit, mapFile, head string) error {
+	mapping, err := git.ReadCommitMap(mapFile)
+	if err != nil {
+		return err
+	}
+	for _, c := range commits {
+		if _, ok := mapping[c.Hash]; !ok {
+			return fmt.Errorf("the rebase did not rewrite %.7s; was it aborted by hand?\nhint: run \"git retime --abort\" to drop the retime", c.Hash)
+		}
+	}
+	if last := mapping[commits[len(commits)-1].Hash]; head != last {
+		return fmt.Errorf("HEAD is at %.7s instead of the rewritten tip %.7s\nhint: run \"git retime --abort\" to drop the retime", head, last)
+	}
+	return nil
+}
+
+// abortSession undoes a rebase left stopped by --no-auto-abort and drops
+// the saved session.
+func abortSession(ctx context.Context) error {
+	s, err := loadSession(ctx)
+	if err != nil {
+		return err
+	}
+	if s.stopped != nil {
+		if err := git.AbortRebase(ctx, s.stopped.Dir, s.stopped.Target); err != nil {
+			return err
+		}
+	}
+	if err := s.remove(); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "retime aborted")
+	return nil
+}
+
+func writeFileAtomic(path, content string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("writing %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/erfnzdeh/git-retime/internal/git"
+	"github.com/erfnzdeh/git-retime/internal/timestamp"
+	"github.com/erfnzdeh/git-retime/internal/todo"
+)
+
+// runTags implements "git retime tags [<pattern>]": the annotated tags
+// matching pattern are listed in a todo like commits are, and the edited
+// tagger dates are written back by recreating the tag objects.
+func runTags(ctx context.Context, args []string) error {
+	flagArgs, positional, _ := reorderArgs(args)
+
+	fs := flag.NewFlagSet("git-retime tags", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the planned tagger dates without rewriting any tag")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: git retime tags [options] [<pattern>]\n\n")
+		fmt.Fprintf(os.Stderr, "Interactively edit the tagger dates of annotated tags.\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  git retime tags                Open editor for all annotated tags\n")
+		fmt.Fprintf(os.Stderr, "  git retime tags 'v1.*'         Open editor for the v1.x release tags\n")
+		fmt.Fprintf(os.Stderr, "\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+	if len(positional) > 1 {
+		return errors.New("expected at most one tag pattern")
+	}
+
+	// No pattern lists every tag: "refs/tags/" is a prefix for
+	// for-each-ref, whereas "*" would not descend into "release/v1".
+	var pattern string
+	if len(positional) == 1 {
+		pattern = positional[0]
+	}
+
+	tags, err := git.ListAnnotatedTags(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		if pattern == "" {
+			return errors.New("no annotated tags")
+		}
+		return fmt.Errorf("no annotated tags match %q", pattern)
+	}
+
+	editor, err := git.GetEditor(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	todoContent := todo.GenerateTags(tags)
+	stateDir, err := git.StateDir(ctx)
+	if err != nil {
+		return err
+	}
+	todoPath := filepath.Join(stateDir, "tags-todo")
+	defer os.Remove(todoPath)
+
+	for {
+		if err := os.WriteFile(todoPath, []byte(todoContent), 0644); err != nil {
+			return fmt.Errorf("writing todo file: %w", err)
+		}
+		if err := git.OpenEditor(editor, todoPath); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+
+		edited, err := os.ReadFile(todoPath)
+		if err != nil {
+			return fmt.Errorf("reading edited todo: %w", err)
+		}
+		content := string(edited)
+
+		if todo.IsAbort(content) {
+			fmt.Fprintln(os.Stderr, "retime aborted")
+			return nil
+		}
+
+		entries, err := todo.Parse(content, false)
+		if err != nil {
+			return err
+		}
+		if err := todo.ValidateTagStructure(entries, tags); err != nil {
+			return err
+		}
+		plan, err := todo.TagsToCommits(entries, tags)
+		if err != nil {
+			return err
+		}
+		if err := timestamp.ResolveAll(plan, now, false); err != nil {
+			return err
+		}
+
+		if *dryRun {
+			printTagPlan(os.Stdout, plan)
+			return nil
+		}
+
+		// Rewriting a signed tag invalidates its signature, so ask first.
+		var unsigned []string
+		for i, t := range tags {
+			if t.Signed && tagChanged(plan[i]) {
+				unsigned = append(unsigned, t.Name)
+			}
+		}
+		if len(unsigned) > 0 {
+			fmt.Fprintln(os.Stderr, "warning: these signed tags will lose their signature:")
+			for _, name := range unsigned {
+				fmt.Fprintln(os.Stderr, "  "+name)
+			}
+			proceed, err := promptYesNo("Proceed anyway?")
+			if err != nil {
+				return err
+			}
+			if !proceed {
+				todoContent = content
+				continue
+			}
+		}
+
+		return retimeTags(ctx, tags, plan)
+	}
+}
+
+// retimeTags rewrites every tag whose date or subject was edited. Untouched
+// tags, and their signatures, are left alone.
+func retimeTags(ctx context.Context, tags []git.TagInfo, plan []timestamp.Commit) error {
+	// An interruption stops between tags, never halfway through one.
+	tagCtx, stop := trapSignals(ctx)
+	defer stop()
+	for i, t := range tags {
+		if err := tagCtx.Err(); err != nil {
+			return fmt.Errorf("interrupted, the remaining tags were left unchanged: %w", err)
+		}
+		c := plan[i]
+		if !tagChanged(c) {
+			continue
+		}
+		subject := ""
+		if c.NewSubject != c.Subject {
+			subject = c.NewSubject
+		}
+		dropped, err := git.RetimeTag(ctx, t, c.ResolvedAuthorDate, subject)
+		if err != nil {
+			return err
+		}
+		if dropped {
+			fmt.Fprintf(os.Stderr, "updated tag %s (signature dropped, re-sign it with git tag -s -f)\n", t.Name)
+		} else {
+			fmt.Fprintf(os.Stderr, "updated tag %s\n", t.Name)
+		}
+	}
+	return nil
+}
+
+func tagChanged(c timestamp.Commit) bool {
+	return !c.ResolvedAuthorDate.Equal(c.OrigAuthorDate) || c.NewSubject != c.Subject
+}
+
+// printTagPlan writes each tag's original and resolved tagger dates.
+func printTagPlan(w io.Writer, plan []timestamp.Commit) {
+	for _, c := range plan {
+		fmt.Fprintf(w, "%s  %s -> %s  %s\n",
+			c.Hash,
+			timestamp.FormatGit(c.OrigAuthorDate),
+			timestamp.FormatGit(c.ResolvedAuthorDate),
+			c.NewSubject,
+		)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// These tests run git-retime from the repository layouts where the git
+// directory is not simply ./.git.
+
+// TestIntegration_Subdirectory verifies retiming from a subdirectory of the
+// working tree, through the todo.
+func TestIntegration_Subdirectory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 3)
+	subDir := filepath.Join(repoDir, "sub", "dir")
+	os.MkdirAll(subDir, 0755)
+	setSubjectEditor(t, "Commit C", "Commit C (sub)")
+
+	runRetime(t, binary, subDir, "HEAD~1")
+
+	if subjects := getSubjects(t, repoDir); subjects[2] != "Commit C (sub)" {
+		t.Errorf("expected edited subject, got %q", subjects[2])
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "retime", "commit-map")); err != nil {
+		t.Errorf("expected commit map in .git/retime: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(subDir, ".git")); err == nil {
+		t.Errorf("state written to the subdirectory")
+	}
+}
+
+// TestIntegration_LinkedWorktree verifies retiming the branch checked out
+// in a linked worktree, whose .git is a file, with per-worktree state.
+func TestIntegration_LinkedWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	wtDir := filepath.Join(t.TempDir(), "wt")
+	runGit(t, repoDir, "worktree", "add", "-q", "-b", "feature", wtDir)
+	mainHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	setSubjectEditor(t, "Commit D", "Commit D (wt)")
+
+	runRetime(t, binary, wtDir, "HEAD~2")
+
+	if subjects := getSubjects(t, wtDir); subjects[3] != "Commit D (wt)" {
+		t.Errorf("expected edited subject in the worktree, got %q", subjects[3])
+	}
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != mainHead {
+		t.Errorf("main worktree's branch moved")
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "worktrees", "wt", "retime", "commit-map")); err != nil {
+		t.Errorf("expected per-worktree commit map: %v", err)
+	}
+}
+
+// TestIntegration_BareRepository verifies retiming the HEAD branch of a
+// bare repository.
+func TestIntegration_BareRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	bareDir := filepath.Join(t.TempDir(), "bare.git")
+	runGit(t, repoDir, "clone", "-q", "--bare", repoDir, bareDir)
+	origDates := getAuthorDates(t, bareDir)
+
+	runRetime(t, binary, bareDir, "HEAD~2", "--shift", "+1h")
+
+	newDates := getAuthorDates(t, bareDir)
+	for i := range origDates {
+		origT, _ := time.Parse(time.RFC3339, origDates[i])
+		newT, _ := time.Parse(time.RFC3339, newDates[i])
+		want := time.Duration(0)
+		if i >= 2 {
+			want = time.Hour
+		}
+		if diff := newT.Sub(origT); diff != want {
+			t.Errorf("commit %d: expected shift %v, got %v", i, want, diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(bareDir, "retime", "commit-map")); err != nil {
+		t.Errorf("expected commit map in the bare repository: %v", err)
+	}
+}
+
+// setSubjectEditor points GIT_EDITOR at a script that renames one subject
+// in the todo.
+func setSubjectEditor(t *testing.T, from, to string) {
+	t.Helper()
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nsed -i 's/  " + from + "$/  " + strings.ReplaceAll(to, "/", `\/`) + "/' \"$1\"\n"
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+}
@@ -4,7 +4,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -125,6 +127,934 @@ func TestIntegration_MessagePreserved(t *testing.T) {
 	}
 }
 
+// TestIntegration_Unique verifies --unique spreads commits that would
+// otherwise share a timestamp.
+func TestIntegration_Unique(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	t.Setenv("TZ", "UTC")
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 5)
+
+	// Rounding down to the day collapses all commits onto midnight;
+	// --unique must then push them one second apart.
+	runRetime(t, binary, repoDir, "HEAD~3", "--shift", "+0h", "--round", "-1d", "--unique")
+
+	newDates := getAuthorDates(t, repoDir)
+	for i := 2; i < 5; i++ {
+		want := time.Date(2026, 1, 15, 0, 0, i-2, 0, time.UTC)
+		got, _ := time.Parse(time.RFC3339, newDates[i])
+		if !got.Equal(want) {
+			t.Errorf("commit %d: expected %s, got %s", i, want.Format(time.RFC3339), newDates[i])
+		}
+	}
+}
+
+// TestIntegration_Scale verifies --scale halves the gaps between commits
+// while keeping the first commit in place.
+func TestIntegration_Scale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 5)
+
+	origDates := getAuthorDates(t, repoDir)
+	runRetime(t, binary, repoDir, "HEAD~4", "--scale", "0.5")
+	newDates := getAuthorDates(t, repoDir)
+
+	// HEAD~4 is the root commit, so all five commits are retimed.
+	first, _ := time.Parse(time.RFC3339, origDates[0])
+	for i := 0; i < 5; i++ {
+		got, _ := time.Parse(time.RFC3339, newDates[i])
+		want := first.Add(time.Duration(i) * 30 * time.Minute)
+		if !got.Equal(want) {
+			t.Errorf("commit %d: expected %s, got %s", i, want.Format(time.RFC3339), newDates[i])
+		}
+	}
+}
+
+// TestIntegration_Coarsen verifies --coarsen truncates both dates to the
+// UTC day, and that --dry-run previews it without rewriting anything.
+func TestIntegration_Coarsen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+
+	out := runRetime(t, binary, repoDir, "HEAD~2", "--coarsen", "1d", "--dry-run")
+	if !strings.Contains(out, "2026-01-15T12:00:00Z -> 2026-01-15T00:00:00Z") {
+		t.Errorf("dry-run output missing coarsened date:\n%s", out)
+	}
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Fatalf("--dry-run rewrote history: %s -> %s", origHead, head)
+	}
+
+	runRetime(t, binary, repoDir, "HEAD~2", "--coarsen", "1d")
+
+	out = runGit(t, repoDir, "log", "--reverse", "--format=%aI %cI")
+	lines := nonEmpty(strings.Split(out, "\n"))
+	for i := 2; i < 4; i++ {
+		if lines[i] != "2026-01-15T00:00:00+00:00 2026-01-15T00:00:00+00:00" {
+			t.Errorf("commit %d: expected UTC midnight for both dates, got %q", i, lines[i])
+		}
+	}
+}
+
+// TestIntegration_Filter verifies that only commits matching the filters
+// are retimed, and that the others keep both of their dates.
+func TestIntegration_Filter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+
+	for name, args := range map[string][]string{
+		"grep":     {"HEAD~4", "--grep", "Commit C", "--shift", "+1h"},
+		"pathspec": {"HEAD~4", "--shift", "+1h", "--", "fff.txt"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repoDir := createTempRepo(t, 5)
+			orig := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI %cI"), "\n"))
+
+			runRetime(t, binary, repoDir, args...)
+
+			updated := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI %cI"), "\n"))
+			for i := range orig {
+				if i == 2 {
+					if updated[i] != "2026-01-15T13:00:00+00:00 2026-01-15T13:00:00+00:00" {
+						t.Errorf("commit %d should be shifted, got %q", i, updated[i])
+					}
+					continue
+				}
+				if updated[i] != orig[i] {
+					t.Errorf("commit %d should keep its dates: orig=%q, new=%q", i, orig[i], updated[i])
+				}
+			}
+		})
+	}
+}
+
+// TestIntegration_FilterJitter verifies that jittered commits do not jump
+// past the unselected commits around them.
+func TestIntegration_FilterJitter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 5)
+	orig := getAuthorDates(t, repoDir)
+
+	for seed := 1; seed <= 20; seed++ {
+		runRetime(t, binary, repoDir, "HEAD~4", "--grep", "Commit [BE]", "--jitter", "3h", "--seed", strconv.Itoa(seed))
+
+		dates := getAuthorDates(t, repoDir)
+		for _, i := range []int{0, 2, 3} {
+			if dates[i] != orig[i] {
+				t.Fatalf("seed %d: commit %d should keep its date: orig=%s, new=%s", seed, i, orig[i], dates[i])
+			}
+		}
+		for i := 1; i < len(dates); i++ {
+			prev, _ := time.Parse(time.RFC3339, dates[i-1])
+			cur, _ := time.Parse(time.RFC3339, dates[i])
+			if !cur.After(prev) {
+				t.Fatalf("seed %d: commit %d (%s) is not after commit %d (%s)", seed, i, dates[i], i-1, dates[i-1])
+			}
+		}
+	}
+}
+
+// TestIntegration_OtherBranch retimes a branch that is checked out in
+// another worktree, from a worktree on a different branch.
+func TestIntegration_OtherBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 5)
+
+	runGit(t, repoDir, "branch", "topic")
+	runGit(t, repoDir, "switch", "-q", "-c", "other", "HEAD~3")
+	runGit(t, repoDir, "worktree", "add", "-q", filepath.Join(t.TempDir(), "wt"), "topic")
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	origDates := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI", "topic"), "\n"))
+
+	runRetime(t, binary, repoDir, "topic~2..topic", "--shift", "+1h")
+
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("checked-out branch moved: %s -> %s", origHead, head)
+	}
+	if branch := strings.TrimSpace(runGit(t, repoDir, "branch", "--show-current")); branch != "other" {
+		t.Errorf("expected to stay on branch other, got %q", branch)
+	}
+
+	newDates := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI", "topic"), "\n"))
+	for i := range origDates {
+		origT, _ := time.Parse(time.RFC3339, origDates[i])
+		newT, _ := time.Parse(time.RFC3339, newDates[i])
+		want := time.Duration(0)
+		if i >= 3 {
+			want = time.Hour
+		}
+		if diff := newT.Sub(origT); diff != want {
+			t.Errorf("commit %d: expected shift %v, got %v", i, want, diff)
+		}
+	}
+
+	if list := runGit(t, repoDir, "worktree", "list"); strings.Count(list, "\n") != 2 {
+		t.Errorf("temporary worktree left behind:\n%s", list)
+	}
+}
+
+// TestIntegration_OutputBranch verifies --output-branch leaves the
+// original branch untouched.
+func TestIntegration_OutputBranch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--output-branch", "retimed")
+
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("original branch moved: %s -> %s", origHead, head)
+	}
+
+	origDates := getAuthorDates(t, repoDir)
+	newDates := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI", "retimed"), "\n"))
+	for i := 2; i < 4; i++ {
+		origT, _ := time.Parse(time.RFC3339, origDates[i])
+		newT, _ := time.Parse(time.RFC3339, newDates[i])
+		if diff := newT.Sub(origT); diff != time.Hour {
+			t.Errorf("commit %d: expected +1h on retimed, got %v", i, diff)
+		}
+	}
+}
+
+// TestIntegration_DefaultBase verifies that without a revision only the
+// commits not on the upstream (or retime.defaultBase) are retimed.
+func TestIntegration_DefaultBase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+
+	for _, tc := range []struct {
+		name  string
+		setup []string
+		// base is where the base branch points; later commits are retimed.
+		base int
+	}{
+		{"upstream", []string{"branch", "--set-upstream-to=base"}, 2},
+		{"config", []string{"config", "retime.defaultBase", "base"}, 2},
+		// A base at the root commit stays exclusive.
+		{"root", []string{"config", "retime.defaultBase", "base"}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := createTempRepo(t, 5)
+			runGit(t, repoDir, "branch", "base", "HEAD~"+strconv.Itoa(4-tc.base))
+			runGit(t, repoDir, tc.setup...)
+
+			origDates := getAuthorDates(t, repoDir)
+			runRetime(t, binary, repoDir, "--shift", "+1h")
+			newDates := getAuthorDates(t, repoDir)
+
+			for i := range origDates {
+				origT, _ := time.Parse(time.RFC3339, origDates[i])
+				newT, _ := time.Parse(time.RFC3339, newDates[i])
+				want := time.Duration(0)
+				if i > tc.base {
+					want = time.Hour
+				}
+				if diff := newT.Sub(origT); diff != want {
+					t.Errorf("commit %d: expected shift %v, got %v", i, want, diff)
+				}
+			}
+		})
+	}
+}
+
+// TestIntegration_PublishedCommits verifies that commits reachable from a
+// remote-tracking ref or a protected branch are not rewritten by default.
+func TestIntegration_PublishedCommits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+
+	for name, setup := range map[string][][]string{
+		"remote":    {{"update-ref", "refs/remotes/origin/main", "HEAD~1"}},
+		"protected": {{"branch", "release/1.0", "HEAD~1"}, {"config", "retime.protectedBranches", "release/*"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repoDir := createTempRepo(t, 5)
+			for _, args := range setup {
+				runGit(t, repoDir, args...)
+			}
+			origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+
+			out := runRetimeFail(t, binary, repoDir, "HEAD~3", "--shift", "+1h")
+			if !strings.Contains(out, "refusing to rewrite published commits") || !strings.Contains(out, "Commit D") {
+				t.Errorf("expected published commits to be listed, got:\n%s", out)
+			}
+			if strings.Contains(out, "Commit E") {
+				t.Errorf("unpublished commit listed as published:\n%s", out)
+			}
+			if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+				t.Fatalf("history rewritten despite refusal")
+			}
+
+			runRetime(t, binary, repoDir, "HEAD~3", "--shift", "+1h", "--force-rewrite-published")
+			if head := runGit(t, repoDir, "rev-parse", "HEAD"); head == origHead {
+				t.Errorf("--force-rewrite-published did not rewrite history")
+			}
+		})
+	}
+}
+
+// TestIntegration_Preflight verifies predictable failures are reported
+// before any rewriting starts.
+func TestIntegration_Preflight(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+
+	tests := []struct {
+		name  string
+		setup [][]string
+		want  string
+	}{
+		{"merge in progress", [][]string{{"update-ref", "MERGE_HEAD", "HEAD~1"}}, "a merge is in progress"},
+		{"detached HEAD", [][]string{{"checkout", "-q", "--detach"}}, "HEAD is detached"},
+		{"dirty worktree", [][]string{{"rm", "-q", "--cached", "f.txt"}}, "uncommitted changes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir := createTempRepo(t, 4)
+			for _, args := range tt.setup {
+				runGit(t, repoDir, args...)
+			}
+			origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+
+			out := runRetimeFail(t, binary, repoDir, "HEAD~2", "--shift", "+1h")
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("expected %q in output, got:\n%s", tt.want, out)
+			}
+			if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+				t.Errorf("history rewritten despite preflight failure")
+			}
+		})
+	}
+}
+
+// TestIntegration_Autostash verifies --autostash retimes a dirty worktree
+// and restores the local changes.
+func TestIntegration_Autostash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	os.WriteFile(filepath.Join(repoDir, "f.txt"), []byte("local change"), 0644)
+
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--autostash")
+
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head == origHead {
+		t.Errorf("history was not rewritten")
+	}
+	data, _ := os.ReadFile(filepath.Join(repoDir, "f.txt"))
+	if string(data) != "local change" {
+		t.Errorf("local change lost, got %q", string(data))
+	}
+}
+
+// TestIntegration_UpdateRefs verifies that --update-refs and --update-tags
+// move stacked branches and tags onto the rewritten commits.
+func TestIntegration_UpdateRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 5)
+	runGit(t, repoDir, "branch", "stacked", "HEAD~1")
+	runGit(t, repoDir, "tag", "light", "HEAD~2")
+	// A quoted key block in the message is not a signature.
+	tagMessage := "Release notes\n\n-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"
+	runGit(t, repoDir, "tag", "-a", "-m", tagMessage, "v1.0", "HEAD~1")
+	origTagDate := runGit(t, repoDir, "for-each-ref", "--format=%(taggerdate:unix)", "refs/tags/v1.0")
+
+	runRetime(t, binary, repoDir, "HEAD~3", "--shift", "+1h", "--update-refs", "--update-tags", "--retime-tag-dates")
+
+	for rev, want := range map[string]string{"stacked": "HEAD~1", "light": "HEAD~2", "v1.0^{commit}": "HEAD~1"} {
+		if got, head := runGit(t, repoDir, "rev-parse", rev), runGit(t, repoDir, "rev-parse", want); got != head {
+			t.Errorf("%s not moved onto rewritten %s", rev, want)
+		}
+	}
+	if msg := strings.TrimSpace(runGit(t, repoDir, "tag", "-l", "--format=%(contents)", "v1.0")); msg != tagMessage {
+		t.Errorf("annotated tag message changed: %q", msg)
+	}
+	origUnix, _ := strconv.ParseInt(strings.TrimSpace(origTagDate), 10, 64)
+	newUnix, _ := strconv.ParseInt(strings.TrimSpace(runGit(t, repoDir, "for-each-ref", "--format=%(taggerdate:unix)", "refs/tags/v1.0")), 10, 64)
+	if newUnix-origUnix != 3600 {
+		t.Errorf("expected tagger date shifted by 1h, got %ds", newUnix-origUnix)
+	}
+}
+
+// TestIntegration_Tags verifies that "git retime tags" rewrites the tagger
+// date of annotated tags and leaves the rest of the tag intact.
+func TestIntegration_Tags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 3)
+	runGit(t, repoDir, "tag", "-a", "-m", "Release 1.0", "v1.0", "HEAD~1")
+	runGit(t, repoDir, "tag", "-a", "-m", "Release 1.1\n\nNotes", "v1.1")
+	target := runGit(t, repoDir, "rev-parse", "v1.0^{commit}")
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nsed -i 's/^v1.0  [^ ]* [^ ]*/v1.0  @1768471200/' \"$1\"\n"
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+
+	runRetime(t, binary, repoDir, "tags", "v1.*")
+
+	if date := strings.TrimSpace(runGit(t, repoDir, "for-each-ref", "--format=%(taggerdate:unix)", "refs/tags/v1.0")); date != "1768471200" {
+		t.Errorf("expected v1.0 tagger date 1768471200, got %s", date)
+	}
+	if got := runGit(t, repoDir, "rev-parse", "v1.0^{commit}"); got != target {
+		t.Errorf("v1.0 target changed: %s -> %s", target, got)
+	}
+	if msg := strings.TrimSpace(runGit(t, repoDir, "tag", "-l", "--format=%(contents)", "v1.0")); msg != "Release 1.0" {
+		t.Errorf("v1.0 message changed: %q", msg)
+	}
+
+	// Without a pattern, hierarchical tags are listed too.
+	runGit(t, repoDir, "tag", "-a", "-m", "Release 2.0", "release/v2.0")
+	script = "#!/bin/sh\nsed -i 's|^release/v2.0  [^ ]* [^ ]*|release/v2.0  @1768474800|' \"$1\"\n"
+	os.WriteFile(editor, []byte(script), 0755)
+	runRetime(t, binary, repoDir, "tags")
+
+	if date := strings.TrimSpace(runGit(t, repoDir, "for-each-ref", "--format=%(taggerdate:unix)", "refs/tags/release/v2.0")); date != "1768474800" {
+		t.Errorf("expected release/v2.0 tagger date 1768474800, got %s", date)
+	}
+}
+
+// TestIntegration_NotesAndAudit verifies that notes follow rewritten
+// commits and that --audit records the original dates.
+func TestIntegration_NotesAndAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	runGit(t, repoDir, "notes", "add", "-m", "reviewed", "HEAD~1")
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--audit")
+
+	if note := strings.TrimSpace(runGit(t, repoDir, "notes", "show", "HEAD~1")); note != "reviewed" {
+		t.Errorf("expected note to follow the rewritten commit, got %q", note)
+	}
+	audit := runGit(t, repoDir, "notes", "--ref=retime", "show", "HEAD")
+	if !strings.Contains(audit, "Original-Author-Date: 2026-01-15T13:00:00Z") || !strings.Contains(audit, "Retimed-By: Test <test@test.com>") {
+		t.Errorf("unexpected audit note:\n%s", audit)
+	}
+}
+
+// TestIntegration_Restore verifies that --restore recovers the original
+// dates of retimed commits from the reflog, and from audit notes once the
+// reflog is gone or only holds retimed versions, after more commits were
+// added on top.
+func TestIntegration_Restore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	origDates := getAuthorDates(t, repoDir)
+	t.Setenv("GIT_EDITOR", "true")
+
+	check := func(label string) {
+		t.Helper()
+		dates := getAuthorDates(t, repoDir)
+		for i := range origDates {
+			if dates[i] != origDates[i] {
+				t.Errorf("%s: commit %d: expected %s, got %s", label, i, origDates[i], dates[i])
+			}
+		}
+	}
+
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+3h")
+	runGit(t, repoDir, "commit", "-q", "--allow-empty", "-m", "Later")
+	runRetime(t, binary, repoDir, "HEAD~3", "--restore")
+	check("reflog")
+
+	runRetime(t, binary, repoDir, "HEAD~3", "--shift", "-2h", "--audit")
+	runGit(t, repoDir, "reflog", "expire", "--expire=now", "--all")
+	runGit(t, repoDir, "gc", "-q", "--prune=now")
+	runRetime(t, binary, repoDir, "HEAD~3", "--restore")
+	check("audit notes")
+
+	// An ordinary rebase leaves the audit notes behind on the old commits,
+	// which the reflog still reaches.
+	runRetime(t, binary, repoDir, "HEAD~3", "--shift", "-2h", "--audit")
+	runGit(t, repoDir, "reflog", "expire", "--expire=now", "--all")
+	runGit(t, repoDir, "update-ref", "-m", "retimed", "HEAD", "HEAD")
+	runGit(t, repoDir, "rebase", "-q", "--force-rebase", "HEAD~3")
+	runRetime(t, binary, repoDir, "HEAD~3", "--restore")
+	check("audit notes of reflog commits")
+}
+
+// TestIntegration_RestoreCommitterDates verifies that committer dates reset
+// by an ordinary rebase are set back while author dates stay put.
+func TestIntegration_RestoreCommitterDates(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	origCommitter := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%cI"), "\n"))
+	origAuthor := getAuthorDates(t, repoDir)
+
+	runGit(t, repoDir, "switch", "-q", "-c", "upstream", "HEAD~2")
+	os.WriteFile(filepath.Join(repoDir, "x.txt"), []byte("upstream"), 0644)
+	runGit(t, repoDir, "add", "x.txt")
+	runGit(t, repoDir, "commit", "-q", "-m", "Upstream change")
+	runGit(t, repoDir, "switch", "-q", "-")
+	runGit(t, repoDir, "rebase", "-q", "upstream")
+
+	runRetime(t, binary, repoDir, "upstream", "--restore-committer-dates")
+
+	committer := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%cI", "upstream.."), "\n"))
+	author := nonEmpty(strings.Split(runGit(t, repoDir, "log", "--reverse", "--format=%aI", "upstream.."), "\n"))
+	if len(committer) != 2 {
+		t.Fatalf("expected 2 rebased commits, got %d", len(committer))
+	}
+	for i := range committer {
+		wantC, _ := time.Parse(time.RFC3339, origCommitter[i+2])
+		gotC, _ := time.Parse(time.RFC3339, committer[i])
+		if !gotC.Equal(wantC) {
+			t.Errorf("commit %d: expected committer date %v, got %v", i, wantC, gotC)
+		}
+		if author[i] != origAuthor[i+2] {
+			t.Errorf("commit %d: author date changed: %s -> %s", i, origAuthor[i+2], author[i])
+		}
+	}
+}
+
+// TestIntegration_CommitMap verifies that the old->new commit map is saved,
+// printed with --print-map and passed to the post-rewrite hook, which is
+// left to git with --run-hooks.
+func TestIntegration_CommitMap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	oldHead := strings.TrimSpace(runGit(t, repoDir, "rev-parse", "HEAD"))
+
+	hookOut := filepath.Join(t.TempDir(), "hook.out")
+	hook := "#!/bin/sh\necho \"$1\" > " + hookOut + "\ncat >> " + hookOut + "\n"
+	os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "post-rewrite"), []byte(hook), 0755)
+
+	out := runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--print-map")
+	newHead := strings.TrimSpace(runGit(t, repoDir, "rev-parse", "HEAD"))
+	line := oldHead + " " + newHead + "\n"
+
+	saved, err := os.ReadFile(filepath.Join(repoDir, ".git", "retime", "commit-map"))
+	if err != nil {
+		t.Fatalf("reading commit map: %v", err)
+	}
+	if lines := nonEmpty(strings.Split(string(saved), "\n")); len(lines) != 2 || !strings.HasSuffix(string(saved), line) {
+		t.Errorf("unexpected commit map:\n%s", saved)
+	}
+	if !strings.Contains(out, line) {
+		t.Errorf("expected --print-map output to contain %q, got:\n%s", line, out)
+	}
+	got, _ := os.ReadFile(hookOut)
+	if string(got) != "rewrite\n"+string(saved) {
+		t.Errorf("unexpected post-rewrite hook input:\n%s", got)
+	}
+
+	// With --run-hooks, git's own post-rewrite calls are the only ones.
+	hook = "#!/bin/sh\necho \"$1\" >> " + hookOut + "\ncat > /dev/null\n"
+	os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "post-rewrite"), []byte(hook), 0755)
+	os.Remove(hookOut)
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--run-hooks")
+	got, _ = os.ReadFile(hookOut)
+	if calls := string(got); strings.Contains(calls, "rewrite") || !strings.HasSuffix(calls, "rebase\n") {
+		t.Errorf("unexpected post-rewrite calls with --run-hooks:\n%s", calls)
+	}
+}
+
+// TestIntegration_Hooks verifies that commit hooks are skipped by default
+// and run with --run-hooks.
+func TestIntegration_Hooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	hooks := filepath.Join(repoDir, ".git", "hooks")
+	os.WriteFile(filepath.Join(hooks, "pre-commit"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+	os.WriteFile(filepath.Join(hooks, "prepare-commit-msg"), []byte("#!/bin/sh\necho mangled >> \"$1\"\n"), 0755)
+	origSubjects := getSubjects(t, repoDir)
+
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h")
+	if msg := runGit(t, repoDir, "log", "-1", "--format=%B"); strings.Contains(msg, "mangled") {
+		t.Errorf("prepare-commit-msg ran during the rewrite:\n%s", msg)
+	}
+
+	// The temporary worktree for another branch is checked out hook-free too.
+	marker := filepath.Join(t.TempDir(), "checked-out")
+	os.WriteFile(filepath.Join(hooks, "post-checkout"), []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755)
+	runGit(t, repoDir, "branch", "other")
+	runRetime(t, binary, repoDir, "HEAD~2", "--branch", "other", "--shift", "+1h")
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("post-checkout ran for the temporary worktree")
+	}
+	os.Remove(filepath.Join(hooks, "post-checkout"))
+
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	runRetimeFail(t, binary, repoDir, "HEAD~2", "--shift", "+1h", "--run-hooks")
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("failing pre-commit hook should leave history untouched")
+	}
+	if subjects := getSubjects(t, repoDir); strings.Join(subjects, ",") != strings.Join(origSubjects, ",") {
+		t.Errorf("subjects changed: %v -> %v", origSubjects, subjects)
+	}
+}
+
+// TestIntegration_EditedMessageVerbatim verifies that an edited subject is
+// applied under a POSIX shell and the rest of the message is kept exactly.
+func TestIntegration_EditedMessageVerbatim(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 2)
+	body := "Body with trailing space  \n\n# not a comment\n\nSigned-off-by: Test <test@test.com>\n"
+	msgFile := filepath.Join(t.TempDir(), "msg")
+	os.WriteFile(msgFile, []byte("Commit C\n\n"+body), 0644)
+	os.WriteFile(filepath.Join(repoDir, "c.txt"), []byte("c"), 0644)
+	runGit(t, repoDir, "add", "c.txt")
+	runGit(t, repoDir, "commit", "-q", "--cleanup=verbatim", "-F", msgFile)
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nsed -i 's/  Commit C$/  It'\\''s \"new\" $HOME \\\\n/' \"$1\"\n"
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+
+	runRetime(t, binary, repoDir, "HEAD~1")
+
+	want := "It's \"new\" $HOME \\n\n\n" + body
+	if got := runGit(t, repoDir, "log", "-1", "--format=%B"); got != want+"\n" {
+		t.Errorf("message not preserved:\ngot  %q\nwant %q", got, want+"\n")
+	}
+}
+
+// TestIntegration_SHA256 verifies retiming in a repository using SHA-256
+// object IDs, through both the plan and the todo paths.
+func TestIntegration_SHA256(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	t.Setenv("GIT_DEFAULT_HASH", "sha256")
+	repoDir := createTempRepo(t, 4)
+	if head := strings.TrimSpace(runGit(t, repoDir, "rev-parse", "HEAD")); len(head) != 64 {
+		t.Fatalf("expected a SHA-256 repository, got HEAD %s", head)
+	}
+	origDates := getAuthorDates(t, repoDir)
+
+	runRetime(t, binary, repoDir, "HEAD~2", "--shift", "+1h")
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\nsed -i 's/  Commit D$/  Commit D (retimed)/' \"$1\"\n"), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+	runRetime(t, binary, repoDir, "HEAD~2")
+
+	newDates := getAuthorDates(t, repoDir)
+	for i := 2; i < 4; i++ {
+		origT, _ := time.Parse(time.RFC3339, origDates[i])
+		newT, _ := time.Parse(time.RFC3339, newDates[i])
+		if diff := newT.Sub(origT); diff != time.Hour {
+			t.Errorf("commit %d: expected +1h, got %v", i, diff)
+		}
+	}
+	if subjects := getSubjects(t, repoDir); subjects[3] != "Commit D (retimed)" {
+		t.Errorf("expected edited subject, got %q", subjects[3])
+	}
+}
+
+// TestIntegration_Editor verifies that the editor string is evaluated by
+// the shell like git does, and that GIT_SEQUENCE_EDITOR takes precedence.
+func TestIntegration_Editor(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 3)
+
+	dir := filepath.Join(t.TempDir(), "My Editor")
+	os.MkdirAll(dir, 0755)
+	editor := filepath.Join(dir, "edit.sh")
+	script := "#!/bin/sh\n[ \"$1\" = --wait ] || exit 1\nsed -i \"s/  Commit C$/  Commit C ($2)/\" \"$3\"\n"
+	os.WriteFile(editor, []byte(script), 0755)
+
+	t.Setenv("GIT_EDITOR", "false")
+	t.Setenv("GIT_SEQUENCE_EDITOR", `"`+editor+`" --wait "$(echo edited)"`)
+	runRetime(t, binary, repoDir, "HEAD~1")
+
+	if subjects := getSubjects(t, repoDir); subjects[2] != "Commit C (edited)" {
+		t.Errorf("expected subject edited through the sequence editor, got %q", subjects[2])
+	}
+}
+
+// TestIntegration_ContinueSession verifies that an edited todo survives a
+// failed run and is reopened by --continue.
+func TestIntegration_ContinueSession(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 3)
+	sessionDir := filepath.Join(repoDir, ".git", "retime", "session")
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\nsed -i 's/  Commit C$/  Commit C (kept)/' \"$1\"\necho 'not a todo line' >> \"$1\"\n"), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+	out := runRetimeFail(t, binary, repoDir, "HEAD~1")
+	if !strings.Contains(out, "--continue") {
+		t.Errorf("expected a --continue hint, got: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(sessionDir, "todo")); err != nil {
+		t.Fatalf("expected the edited todo to be kept: %v", err)
+	}
+
+	os.WriteFile(editor, []byte("#!/bin/sh\nsed -i '/^not a todo line$/d' \"$1\"\n"), 0755)
+	runRetime(t, binary, repoDir, "--continue")
+
+	if subjects := getSubjects(t, repoDir); subjects[2] != "Commit C (kept)" {
+		t.Errorf("expected the first edit to survive, got %q", subjects[2])
+	}
+	if _, err := os.Stat(sessionDir); !os.IsNotExist(err) {
+		t.Errorf("expected the session to be removed after success")
+	}
+	runRetimeFail(t, binary, repoDir, "--continue")
+
+	// A session whose range has moved on cannot be continued.
+	os.WriteFile(editor, []byte("#!/bin/sh\necho 'not a todo line' >> \"$1\"\n"), 0755)
+	runRetimeFail(t, binary, repoDir, "HEAD~1")
+	runGit(t, repoDir, "commit", "-q", "--allow-empty", "-m", "Commit D")
+	if out := runRetimeFail(t, binary, repoDir, "--continue"); !strings.Contains(out, "has moved") {
+		t.Errorf("expected a moved-HEAD error, got: %s", out)
+	}
+}
+
+// TestIntegration_NoAutoAbort verifies that --no-auto-abort leaves a failed
+// rebase stopped, and that --abort undoes it and --continue finishes it.
+func TestIntegration_NoAutoAbort(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 3)
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	origDates := getAuthorDates(t, repoDir)
+	hook := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+	os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755)
+	args := []string{"HEAD~2", "--shift", "+1h", "--run-hooks", "--no-auto-abort"}
+
+	out := runRetimeFail(t, binary, repoDir, args...)
+	if !strings.Contains(out, "git retime --continue") {
+		t.Errorf("expected a --continue hint, got: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "rebase-merge")); err != nil {
+		t.Fatalf("expected the rebase to be left stopped: %v", err)
+	}
+	runRetimeFail(t, binary, repoDir, "HEAD~1", "--shift", "+1h", "--no-auto-abort")
+
+	runRetime(t, binary, repoDir, "--abort")
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("expected --abort to restore HEAD")
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "retime", "session")); !os.IsNotExist(err) {
+		t.Errorf("expected --abort to remove the session")
+	}
+
+	// A rebase aborted by hand is not taken for a finished one.
+	runRetimeFail(t, binary, repoDir, args...)
+	runGit(t, repoDir, "rebase", "--abort")
+	if out := runRetimeFail(t, binary, repoDir, "--continue"); !strings.Contains(out, "git retime --abort") {
+		t.Errorf("expected an --abort hint, got: %s", out)
+	}
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("expected HEAD to stay at the original tip")
+	}
+	runRetime(t, binary, repoDir, "--abort")
+
+	runRetimeFail(t, binary, repoDir, args...)
+	os.Remove(hook)
+	out = runRetime(t, binary, repoDir, "--continue")
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "rebase-merge")); !os.IsNotExist(err) {
+		t.Errorf("expected the rebase to be finished, output: %s", out)
+	}
+
+	// The failed amend is retried, so every commit is retimed.
+	newDates := getAuthorDates(t, repoDir)
+	for i := 0; i < 3; i++ {
+		origT, _ := time.Parse(time.RFC3339, origDates[i])
+		newT, _ := time.Parse(time.RFC3339, newDates[i])
+		if diff := newT.Sub(origT); diff != time.Hour {
+			t.Errorf("commit %d: expected shift 1h, got %v", i, diff)
+		}
+	}
+	mapData, err := os.ReadFile(filepath.Join(repoDir, ".git", "retime", "commit-map"))
+	if err != nil || len(nonEmpty(strings.Split(string(mapData), "\n"))) != 3 {
+		t.Errorf("expected a commit map with 3 entries, got %q (%v)", mapData, err)
+	}
+}
+
+// TestIntegration_ContinueAfterConflict verifies that --continue after a
+// resolved conflict does not open the editor: the rebase has no terminal,
+// and the todo sets every message already.
+func TestIntegration_ContinueAfterConflict(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 1)
+	file := filepath.Join(repoDir, "f.txt")
+
+	// Linearizing the merge replays "side" on top of "main", which
+	// conflicts.
+	commit := func(content, subject, date string) {
+		t.Setenv("GIT_AUTHOR_DATE", date)
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		os.WriteFile(file, []byte(content), 0644)
+		runGit(t, repoDir, "commit", "-q", "-a", "-m", subject)
+	}
+	runGit(t, repoDir, "checkout", "-q", "-b", "side")
+	commit("side\n", "Side", "2026-01-15T11:00:00Z")
+	runGit(t, repoDir, "checkout", "-q", "-")
+	commit("main\n", "Main", "2026-01-15T12:00:00Z")
+	exec.Command("git", "-C", repoDir, "merge", "-q", "side").Run()
+	commit("merged\n", "Merge side", "2026-01-15T13:00:00Z")
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+
+	marker := filepath.Join(t.TempDir(), "edited")
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755)
+	t.Setenv("GIT_EDITOR", editor)
+
+	out := runRetimeFail(t, binary, repoDir, "HEAD~1", "--shift", "+1h", "--no-auto-abort")
+	if !strings.Contains(out, "CONFLICT") {
+		t.Fatalf("expected the rebase to stop on a conflict, got: %s", out)
+	}
+	os.WriteFile(file, []byte("side\n"), 0644)
+	runGit(t, repoDir, "add", "f.txt")
+
+	// The flat todo cannot replay the merge itself, so the rebase may stop
+	// again there; what matters is that the resolved commit went through.
+	cmd := exec.Command(binary, "--continue")
+	cmd.Dir = repoDir
+	out2, _ := cmd.CombinedOutput()
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("--continue opened the editor\noutput: %s", out2)
+	}
+	if subject := strings.TrimSpace(runGit(t, repoDir, "log", "-1", "--format=%s")); subject != "Side" {
+		t.Errorf("expected the resolved commit to be committed, HEAD is %q\noutput: %s", subject, out2)
+	}
+
+	runRetime(t, binary, repoDir, "--abort")
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("expected --abort to restore HEAD")
+	}
+}
+
+// TestIntegration_Interrupt verifies that Ctrl-C during the rebase rolls it
+// back instead of leaving the repository mid-rebase, and drops the session.
+func TestIntegration_Interrupt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	binary := buildBinary(t)
+	repoDir := createTempRepo(t, 4)
+	origHead := runGit(t, repoDir, "rev-parse", "HEAD")
+	// A slow hook keeps the rebase running long enough to interrupt it.
+	os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "pre-commit"), []byte("#!/bin/sh\nsleep 1\n"), 0755)
+
+	cmd := exec.Command(binary, "HEAD~3", "--shift", "+1h", "--run-hooks", "--no-auto-abort")
+	cmd.Dir = repoDir
+	// Like a terminal, deliver the signal to the whole process group.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var out strings.Builder
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Wait until the first commit has been retimed.
+	rebaseDir := filepath.Join(repoDir, ".git", "rebase-merge")
+	mapFile := filepath.Join(repoDir, ".git", "retime", "session", "rebase", "map")
+	for i := 0; i < 100; i++ {
+		if info, err := os.Stat(mapFile); err == nil && info.Size() > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+	if err := cmd.Wait(); err == nil {
+		t.Fatalf("expected the interrupted retime to fail\noutput: %s", out.String())
+	}
+
+	if !strings.Contains(out.String(), "interrupted") {
+		t.Errorf("expected an interruption message, got: %s", out.String())
+	}
+	if _, err := os.Stat(rebaseDir); !os.IsNotExist(err) {
+		t.Errorf("expected the rebase to be aborted\noutput: %s", out.String())
+	}
+	if head := runGit(t, repoDir, "rev-parse", "HEAD"); head != origHead {
+		t.Errorf("expected HEAD to be restored\noutput: %s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", "retime", "session")); !os.IsNotExist(err) {
+		t.Errorf("expected the session to be removed")
+	}
+}
+
 func buildBinary(t *testing.T) string {
 	t.Helper()
 	tmpBin := filepath.Join(t.TempDir(), "git-retime")
@@ -188,7 +1118,7 @@ func createTempRepo(t *testing.T, numCommits int) string {
 	return dir
 }
 
-func runRetime(t *testing.T, binary, repoDir string, args ...string) {
+func runRetime(t *testing.T, binary, repoDir string, args ...string) string {
 	t.Helper()
 	cmd := exec.Command(binary, args...)
 	cmd.Dir = repoDir
@@ -202,6 +1132,19 @@ func runRetime(t *testing.T, binary, repoDir string, args ...string) {
 	if err != nil {
 		t.Fatalf("git-retime %v failed: %v\noutput: %s", args, err, string(out))
 	}
+	return string(out)
+}
+
+// runRetimeFail runs git-retime, expecting it to fail, and returns its output.
+func runRetimeFail(t *testing.T, binary, repoDir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("git-retime %v succeeded, expected failure\noutput: %s", args, string(out))
+	}
+	return string(out)
 }
 
 func runGit(t *testing.T, repoDir string, args ...string) string {
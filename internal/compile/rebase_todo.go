@@ -2,37 +2,51 @@ package compile
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	ts "github.com/erfnzdeh/git-retime/internal/timestamp"
 )
 
+// Options tunes the compiled todo.
+type Options struct {
+	// MapFile, when set, receives one "<old-hash> <new-hash>" line per
+	// commit as the rebase rewrites it.
+	MapFile string
+	// RunHooks lets the amend run the pre-commit and commit-msg hooks,
+	// which are skipped with --no-verify by default.
+	RunHooks bool
+	// MessageDir holds the rewritten messages written from MessageFiles;
+	// commits whose subject changed are amended with git commit -F.
+	MessageDir string
+}
+
 // Compile translates resolved commits into a git rebase-todo file.
 // Each commit becomes a "pick" line followed by an "exec" line that
 // amends the commit's timestamps (and optionally the message).
-func Compile(commits []ts.Commit) string {
+func Compile(commits []ts.Commit, opts Options) string {
 	var b strings.Builder
 
 	for _, c := range commits {
-		shortHash := c.Hash
-		if len(shortHash) > 7 {
-			shortHash = shortHash[:7]
-		}
-
 		subject := c.NewSubject
 		if subject == "" {
 			subject = c.Subject
 		}
 
-		fmt.Fprintf(&b, "pick %s %s\n", shortHash, subject)
-		b.WriteString(buildExec(c))
+		// The full object ID can never be ambiguous, whatever the size of
+		// the repository or its hash algorithm.
+		fmt.Fprintf(&b, "pick %s %s\n", c.Hash, subject)
+		b.WriteString(buildExec(c, opts))
 		b.WriteByte('\n')
+		if opts.MapFile != "" {
+			fmt.Fprintf(&b, "exec echo \"%s $(git rev-parse HEAD)\" >> %q\n", c.Hash, opts.MapFile)
+		}
 	}
 
 	return b.String()
 }
 
-func buildExec(c ts.Commit) string {
+func buildExec(c ts.Commit, opts Options) string {
 	authorDate := ts.FormatGit(c.ResolvedAuthorDate)
 	commitDate := ts.FormatGit(c.ResolvedCommitDate)
 
@@ -45,34 +59,41 @@ func buildExec(c ts.Commit) string {
 	parts = append(parts, "exec")
 	parts = append(parts, fmt.Sprintf("GIT_COMMITTER_DATE=%q", commitDate))
 
+	amend := "git commit --amend"
+	if !opts.RunHooks {
+		amend += " --no-verify"
+	}
+
 	if subjectChanged {
-		parts = append(parts, "git commit --amend --allow-empty")
+		// Reading the message from a file avoids shell quoting entirely
+		// (exec lines run under /bin/sh, often dash), and verbatim cleanup
+		// keeps whitespace, comment-like lines and trailers as they are.
+		parts = append(parts, amend+" --allow-empty --cleanup=verbatim")
 		parts = append(parts, fmt.Sprintf("--date=%q", authorDate))
-		parts = append(parts, buildMessageArgs(c)...)
+		parts = append(parts, fmt.Sprintf("-F %q", filepath.Join(opts.MessageDir, c.Hash)))
 	} else {
-		parts = append(parts, "git commit --amend --no-edit --allow-empty")
+		parts = append(parts, amend+" --no-edit --allow-empty")
 		parts = append(parts, fmt.Sprintf("--date=%q", authorDate))
 	}
 
 	return strings.Join(parts, " ")
 }
 
-func buildMessageArgs(c ts.Commit) []string {
-	// Reconstruct the full message: new subject + original body.
-	fullMsg := c.NewSubject
-	if c.Body != "" {
-		fullMsg += "\n\n" + c.Body
+// MessageFiles returns the full rewritten message of every commit whose
+// subject changed, keyed by the file name Compile expects in MessageDir.
+// The new subject replaces the original subject paragraph; the rest of the
+// message is kept byte for byte.
+func MessageFiles(commits []ts.Commit) map[string]string {
+	files := make(map[string]string)
+	for _, c := range commits {
+		if c.NewSubject == "" || c.NewSubject == c.Subject {
+			continue
+		}
+		msg := c.NewSubject + "\n"
+		if c.Body != "" {
+			msg += "\n" + c.Body
+		}
+		files[c.Hash] = msg
 	}
-	return []string{"-m", shellQuote(fullMsg)}
-}
-
-func shellQuote(s string) string {
-	// Use $'...' quoting for strings with newlines, single quotes, etc.
-	replacer := strings.NewReplacer(
-		`\`, `\\`,
-		`'`, `\'`,
-		"\n", `\n`,
-		"\t", `\t`,
-	)
-	return "$'" + replacer.Replace(s) + "'"
+	return files
 }
@@ -19,9 +19,9 @@ func TestCompile_Basic(t *testing.T) {
 		},
 	}
 
-	result := Compile(commits)
+	result := Compile(commits, Options{})
 
-	if !strings.Contains(result, "pick abc1234 Fix navbar") {
+	if !strings.Contains(result, "pick abc1234abcd Fix navbar") {
 		t.Errorf("expected pick line, got:\n%s", result)
 	}
 	if !strings.Contains(result, "exec") {
@@ -47,13 +47,36 @@ func TestCompile_ChangedMessage(t *testing.T) {
 		},
 	}
 
-	result := Compile(commits)
+	result := Compile(commits, Options{MessageDir: "/tmp/msgs"})
 
 	if strings.Contains(result, "--no-edit") {
 		t.Errorf("should NOT have --no-edit for changed message, got:\n%s", result)
 	}
-	if !strings.Contains(result, "-m") {
-		t.Errorf("expected -m for changed message, got:\n%s", result)
+	if !strings.Contains(result, `--cleanup=verbatim`) || !strings.Contains(result, `-F "/tmp/msgs/abc1234abcd"`) {
+		t.Errorf("expected verbatim -F for changed message, got:\n%s", result)
+	}
+	if strings.Contains(result, "$'") {
+		t.Errorf("expected no bash-only quoting, got:\n%s", result)
+	}
+}
+
+func TestMessageFiles(t *testing.T) {
+	commits := []ts.Commit{
+		{Hash: "abc1234abcd", Subject: "Same", NewSubject: "Same", Body: "Body\n"},
+		{Hash: "def5678efgh", Subject: "Old", NewSubject: "It's new", Body: "Body  \n\n# not a comment\nSigned-off-by: A <a@b>\n"},
+		{Hash: "0123456789a", Subject: "Old", NewSubject: "No body"},
+	}
+
+	files := MessageFiles(commits)
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 message files, got %d", len(files))
+	}
+	if want := "It's new\n\nBody  \n\n# not a comment\nSigned-off-by: A <a@b>\n"; files["def5678efgh"] != want {
+		t.Errorf("got %q, want %q", files["def5678efgh"], want)
+	}
+	if files["0123456789a"] != "No body\n" {
+		t.Errorf("got %q, want %q", files["0123456789a"], "No body\n")
 	}
 }
 
@@ -75,7 +98,7 @@ func TestCompile_MultipleCommits(t *testing.T) {
 		},
 	}
 
-	result := Compile(commits)
+	result := Compile(commits, Options{})
 
 	lines := strings.Split(strings.TrimSpace(result), "\n")
 	pickCount := 0
@@ -96,3 +119,41 @@ func TestCompile_MultipleCommits(t *testing.T) {
 		t.Errorf("expected 2 exec lines, got %d", execCount)
 	}
 }
+
+func TestCompile_MapFile(t *testing.T) {
+	commits := []ts.Commit{
+		{
+			Hash:               "abc1234abcd",
+			Subject:            "First",
+			NewSubject:         "First",
+			ResolvedAuthorDate: time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC),
+			ResolvedCommitDate: time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	result := Compile(commits, Options{MapFile: "/tmp/commit-map"})
+
+	want := `exec echo "abc1234abcd $(git rev-parse HEAD)" >> "/tmp/commit-map"`
+	if !strings.Contains(result, want) {
+		t.Errorf("expected map line %q, got:\n%s", want, result)
+	}
+}
+
+func TestCompile_Hooks(t *testing.T) {
+	commits := []ts.Commit{
+		{
+			Hash:               "abc1234abcd",
+			Subject:            "Fix navbar",
+			NewSubject:         "Fix navbar",
+			ResolvedAuthorDate: time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC),
+			ResolvedCommitDate: time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	if result := Compile(commits, Options{}); !strings.Contains(result, "git commit --amend --no-verify") {
+		t.Errorf("expected hooks to be skipped by default, got:\n%s", result)
+	}
+	if result := Compile(commits, Options{RunHooks: true}); strings.Contains(result, "--no-verify") {
+		t.Errorf("expected no --no-verify with RunHooks, got:\n%s", result)
+	}
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SaveCommitMap writes the old->new commit map of the last rewrite to
+// commit-map in the StateDir and returns its path. Each line is
+// "<old-hash> <new-hash>", the format git hands to post-rewrite hooks.
+func SaveCommitMap(ctx context.Context, content string) (string, error) {
+	dir, err := StateDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "commit-map")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("writing commit map: %w", err)
+	}
+	return path, nil
+}
+
+// RunPostRewriteHook runs the post-rewrite hook, if one is installed, with
+// "rewrite" as its argument and the commit map on stdin, the way git runs
+// it after amend and rebase. Hook output goes to stderr.
+func RunPostRewriteHook(ctx context.Context, mapPath string) error {
+	hook := gitPath(ctx, "hooks/post-rewrite")
+	info, err := os.Stat(hook)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return nil
+	}
+
+	in, err := os.Open(mapPath)
+	if err != nil {
+		return fmt.Errorf("reading commit map: %w", err)
+	}
+	defer in.Close()
+
+	cmd := exec.CommandContext(ctx, hook, "rewrite")
+	if out, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		cmd.Dir = strings.TrimSpace(string(out))
+	}
+	cmd.Stdin = in
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("post-rewrite hook failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// StateDir returns the directory for git-retime's own files, retime/ inside
+// the git directory, creating it if needed. The git directory is asked from
+// git, so this works from subdirectories, in bare repositories and in
+// linked worktrees, where each worktree gets its own state directory.
+func StateDir(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--absolute-git-dir").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate the git directory: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+	dir := filepath.Join(strings.TrimSpace(string(out)), "retime")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// IsBare reports whether the repository has no working tree.
+func IsBare(ctx context.Context) bool {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--is-bare-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
@@ -1,17 +1,31 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 )
 
-// GetEditor resolves the user's preferred editor using git's own resolution
-// logic: GIT_SEQUENCE_EDITOR -> GIT_EDITOR -> core.editor -> VISUAL -> EDITOR -> vi
-func GetEditor() (string, error) {
-	out, err := exec.Command("git", "var", "GIT_EDITOR").CombinedOutput()
+// GetEditor resolves the editor for the todo the way git resolves the one
+// for a rebase todo: GIT_SEQUENCE_EDITOR -> sequence.editor -> GIT_EDITOR ->
+// core.editor -> VISUAL -> EDITOR -> vi. The last four are left to
+// git var GIT_EDITOR, which also refuses the vi fallback on a dumb terminal.
+func GetEditor(ctx context.Context) (string, error) {
+	if editor := os.Getenv("GIT_SEQUENCE_EDITOR"); editor != "" {
+		return editor, nil
+	}
+	if editor := configValue(ctx, "sequence.editor"); editor != "" {
+		return editor, nil
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "var", "GIT_EDITOR").CombinedOutput()
 	if err != nil {
+		if isTerminalDumb() {
+			return "", errors.New("terminal is dumb, but no editor is configured\nhint: set GIT_EDITOR, core.editor, VISUAL or EDITOR")
+		}
 		return "", fmt.Errorf("cannot determine editor: %s\n%s", err, string(out))
 	}
 	editor := strings.TrimSpace(string(out))
@@ -23,17 +37,42 @@ func GetEditor() (string, error) {
 
 // OpenEditor launches the editor with the given file path, connecting it
 // to the user's terminal (stdin/stdout/stderr).
+//
+// Like git, the editor string is evaluated by the shell, so quoted paths
+// with spaces, arguments and shell expansions all work; ":" means "do not
+// edit". It takes no context, so an editor is never cut off mid-edit.
 func OpenEditor(editor, filePath string) error {
-	parts := strings.Fields(editor)
-	if len(parts) == 0 {
+	if editor == "" {
 		return fmt.Errorf("empty editor command")
 	}
+	if editor == ":" {
+		return nil
+	}
+
+	waiting := stderrIsTerminal() && !isTerminalDumb()
+	if waiting {
+		fmt.Fprint(os.Stderr, "hint: Waiting for your editor to close the file... ")
+	}
 
-	args := append(parts[1:], filePath)
-	cmd := exec.Command(parts[0], args...)
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, filePath)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	if waiting {
+		// Erase the hint again, as git does.
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	return err
+}
+
+func isTerminalDumb() bool {
+	term := os.Getenv("TERM")
+	return term == "" || term == "dumb"
+}
 
-	return cmd.Run()
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Filter restricts which commits in the range get new timestamps. Empty
+// fields do not filter. Values are passed to git rev-list as-is, so Since
+// and Until accept anything git understands ("2 weeks ago", a date, ...).
+type Filter struct {
+	Author string
+	Grep   string
+	Since  string
+	Until  string
+	Paths  []string
+}
+
+// IsEmpty reports whether the filter selects every commit.
+func (f Filter) IsEmpty() bool {
+	return f.Author == "" && f.Grep == "" && f.Since == "" && f.Until == "" && len(f.Paths) == 0
+}
+
+// MarkContext sets Context on every commit that does not match the filter.
+// base is the exclusive base returned by FetchCommits; an empty base means
+// the range starts at the root commit. tip is the end of the range.
+func MarkContext(ctx context.Context, commits []CommitInfo, base, tip string, f Filter) error {
+	if f.IsEmpty() {
+		return nil
+	}
+
+	args := []string{"rev-list"}
+	if f.Author != "" {
+		args = append(args, "--author="+f.Author)
+	}
+	if f.Grep != "" {
+		args = append(args, "--grep="+f.Grep)
+	}
+	if f.Since != "" {
+		args = append(args, "--since="+f.Since)
+	}
+	if f.Until != "" {
+		args = append(args, "--until="+f.Until)
+	}
+	if base == "" {
+		args = append(args, tip)
+	} else {
+		args = append(args, base+".."+tip)
+	}
+	args = append(args, "--")
+	args = append(args, f.Paths...)
+
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("selecting commits: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	matched := make(map[string]bool)
+	for _, h := range strings.Fields(string(out)) {
+		matched[h] = true
+	}
+	for i := range commits {
+		commits[i].Context = !matched[commits[i].Hash]
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -13,7 +14,13 @@ type CommitInfo struct {
 	AuthorDate time.Time
 	CommitDate time.Time
 	Subject    string
-	Body       string
+	// Body is the raw message after the subject paragraph, byte for byte
+	// (including trailing whitespace and trailers), or "" if there is none.
+	Body string
+
+	// Context marks a commit outside the selected set (see Filter). It is
+	// still replayed by the rebase but keeps its original dates.
+	Context bool
 }
 
 const fieldSep = "\x1f"
@@ -21,8 +28,8 @@ const recordSep = "\x1e"
 
 // ResolveRevision uses git rev-parse to resolve an arbitrary revision
 // expression to a full commit hash.
-func ResolveRevision(rev string) (string, error) {
-	out, err := exec.Command("git", "rev-parse", rev).CombinedOutput()
+func ResolveRevision(ctx context.Context, rev string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", rev).CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("cannot resolve revision %q: %s\n%s", rev, err, strings.TrimSpace(string(out)))
 	}
@@ -32,26 +39,31 @@ func ResolveRevision(rev string) (string, error) {
 // FetchCommits resolves a revision and fetches the commits to retime.
 //
 // Semantics match git rebase -i: the revision is the base (exclusive).
-// Commits after it up to HEAD are included. If the revision is the root
-// commit (no parent), it is also included and needsRoot is set so the
-// rebase uses --root.
-func FetchCommits(revision string) (commits []CommitInfo, base string, needsRoot bool, err error) {
-	resolved, err := ResolveRevision(revision)
+// Commits after it up to tip (usually HEAD) are included. If the revision
+// is the root commit (no parent), it is also included and needsRoot is set
+// so the rebase uses --root. With exclusive set the revision is never
+// included, not even the root: a base computed from a shared upstream
+// must stay untouched.
+func FetchCommits(ctx context.Context, revision, tip string, exclusive bool) (commits []CommitInfo, base string, needsRoot bool, err error) {
+	resolved, err := ResolveRevision(ctx, revision)
 	if err != nil {
 		return nil, "", false, err
 	}
 
-	// Fetch commits after revision up to HEAD.
-	afterCommits, err := fetchLog(resolved + "..HEAD")
+	// Fetch commits after revision up to the tip.
+	afterCommits, err := fetchLog(ctx, resolved+".."+tip)
 	if err != nil {
 		return nil, "", false, err
 	}
+	if exclusive {
+		return afterCommits, resolved, false, nil
+	}
 
 	// Check whether the resolved revision itself is the root commit.
-	_, parentErr := exec.Command("git", "rev-parse", "--verify", "--quiet", resolved+"^").CombinedOutput()
+	_, parentErr := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", resolved+"^").CombinedOutput()
 	if parentErr != nil {
 		// Revision is the root commit — include it and use --root for rebase.
-		rootCommits, err := fetchLog("-1 " + resolved)
+		rootCommits, err := fetchLog(ctx, "-1 "+resolved)
 		if err != nil {
 			return nil, "", false, fmt.Errorf("fetching root commit: %w", err)
 		}
@@ -62,13 +74,13 @@ func FetchCommits(revision string) (commits []CommitInfo, base string, needsRoot
 	return afterCommits, resolved, false, nil
 }
 
-func fetchLog(rangeExpr string) ([]CommitInfo, error) {
-	format := strings.Join([]string{"%H", "%h", "%aI", "%cI", "%s", "%b"}, fieldSep) + recordSep
+func fetchLog(ctx context.Context, rangeExpr string) ([]CommitInfo, error) {
+	format := strings.Join([]string{"%H", "%h", "%aI", "%cI", "%s", "%B"}, fieldSep) + recordSep
 
 	args := []string{"log", "--format=" + format, "--reverse"}
 	args = append(args, strings.Fields(rangeExpr)...)
 
-	out, err := exec.Command("git", args...).CombinedOutput()
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("git log failed: %s\n%s", err, strings.TrimSpace(string(out)))
 	}
@@ -81,8 +93,10 @@ func parseLogOutput(output string) ([]CommitInfo, error) {
 	var commits []CommitInfo
 
 	for _, rec := range records {
-		rec = strings.TrimSpace(rec)
-		if rec == "" {
+		// Only strip the newline git puts between records; the message
+		// at the end must stay intact.
+		rec = strings.TrimLeft(rec, "\n")
+		if strings.TrimSpace(rec) == "" {
 			continue
 		}
 
@@ -102,7 +116,7 @@ func parseLogOutput(output string) ([]CommitInfo, error) {
 
 		body := ""
 		if len(fields) == 6 {
-			body = strings.TrimSpace(fields[5])
+			_, body, _ = strings.Cut(fields[5], "\n\n")
 		}
 
 		commits = append(commits, CommitInfo{
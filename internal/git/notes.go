@@ -0,0 +1,80 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// AuditNotesRef holds the notes written by --audit.
+const AuditNotesRef = "refs/notes/retime"
+
+// CopyNotes copies the notes of every rewritten commit to its replacement,
+// for each notes ref in the repository. Rebase only does this for the refs
+// listed in notes.rewriteRef, so without it notes would stay behind on the
+// old commits.
+func CopyNotes(ctx context.Context, mapping map[string]string) error {
+	refs, err := forEachRef(ctx, []string{"refs/notes/"})
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+
+	var pairs strings.Builder
+	for old, newHash := range mapping {
+		if old != newHash {
+			fmt.Fprintf(&pairs, "%s %s\n", old, newHash)
+		}
+	}
+	if pairs.Len() == 0 {
+		return nil
+	}
+
+	for _, ref := range refs {
+		cmd := exec.CommandContext(ctx, "git", "notes", "--ref="+ref, "copy", "--force", "--stdin")
+		cmd.Stdin = strings.NewReader(pairs.String())
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("copying notes in %s: %s\n%s", ref, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// AuditEntry records the original dates of one retimed commit.
+type AuditEntry struct {
+	Commit         string
+	OrigAuthorDate time.Time
+	OrigCommitDate time.Time
+}
+
+// WriteAuditNotes appends a note under AuditNotesRef to each commit,
+// recording its original dates and who retimed it. Notes from earlier
+// retimes are kept, so the first paragraph always holds the dates the
+// commit was originally created with.
+func WriteAuditNotes(ctx context.Context, entries []AuditEntry, now time.Time) error {
+	ident, err := exec.CommandContext(ctx, "git", "var", "GIT_COMMITTER_IDENT").Output()
+	if err != nil {
+		return fmt.Errorf("reading committer identity: %w", err)
+	}
+	// Drop the trailing "<unix-seconds> <offset>".
+	who, _, err := parseIdentDate(strings.TrimSpace(string(ident)))
+	if err != nil {
+		return err
+	}
+	who = strings.TrimSpace(who)
+
+	for _, e := range entries {
+		msg := fmt.Sprintf("Original-Author-Date: %s\nOriginal-Committer-Date: %s\nRetimed-By: %s\nRetimed-At: %s\n",
+			e.OrigAuthorDate.Format(time.RFC3339),
+			e.OrigCommitDate.Format(time.RFC3339),
+			who,
+			now.Format(time.RFC3339),
+		)
+		out, err := exec.CommandContext(ctx, "git", "notes", "--ref="+AuditNotesRef, "append", "-m", msg, e.Commit).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("writing audit note for %s: %s\n%s", e.Commit, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
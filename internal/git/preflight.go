@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// inProgress maps state files in the git directory to the operation they
+// indicate and how to finish it.
+var inProgress = []struct {
+	path, op, hint string
+}{
+	{"rebase-merge", "a rebase", "git rebase --continue or git rebase --abort"},
+	{"rebase-apply", "a rebase or am", "git rebase --continue, git am --continue, or their --abort"},
+	{"MERGE_HEAD", "a merge", "git commit or git merge --abort"},
+	{"CHERRY_PICK_HEAD", "a cherry-pick", "git cherry-pick --continue or git cherry-pick --abort"},
+	{"REVERT_HEAD", "a revert", "git revert --continue or git revert --abort"},
+	{"BISECT_LOG", "a bisect", "git bisect reset"},
+}
+
+// Preflight reports, up front, repository states that would make the
+// rewrite fail halfway: an operation already in progress, a detached or
+// dirty HEAD, a range crossing a shallow clone's boundary, or a missing
+// committer identity. All problems are listed together.
+//
+// HEAD-related checks only apply when the checked-out HEAD is rewritten in
+// place; a dirty tree is accepted when autostash is set.
+func Preflight(ctx context.Context, commits []CommitInfo, target Target, autostash bool) error {
+	var problems []string
+
+	for _, s := range inProgress {
+		if pathExists(gitPath(ctx, s.path)) {
+			problems = append(problems, fmt.Sprintf("%s is in progress\n  hint: finish it first with %s", s.op, s.hint))
+		}
+	}
+
+	if target.InPlace() {
+		if currentBranch(ctx) == "" {
+			problems = append(problems, "HEAD is detached\n  hint: check out a branch, or use --output-branch to write the result to a new branch")
+		}
+		if !autostash && isDirty(ctx) {
+			problems = append(problems, "you have uncommitted changes\n  hint: commit or stash them, or pass --autostash")
+		}
+	}
+
+	if boundary := shallowBoundary(ctx, commits); boundary != "" {
+		problems = append(problems, fmt.Sprintf("the range crosses the shallow clone boundary at %s\n  hint: fetch more history with git fetch --deepen=<n> or git fetch --unshallow", boundary))
+	}
+
+	if err := exec.CommandContext(ctx, "git", "var", "GIT_COMMITTER_IDENT").Run(); err != nil {
+		problems = append(problems, "no committer identity is configured\n  hint: git config user.name \"Your Name\" && git config user.email you@example.com")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("cannot retime:\n- " + strings.Join(problems, "\n- "))
+}
+
+// gitPath resolves a path inside the git directory, honoring worktrees.
+func gitPath(ctx context.Context, name string) string {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", name).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func pathExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isDirty(ctx context.Context) bool {
+	out, err := exec.CommandContext(ctx, "git", "status", "--porcelain", "--untracked-files=no").Output()
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+// shallowBoundary returns the short hash of the first commit in the range
+// whose parents were cut off by a shallow clone, or "".
+func shallowBoundary(ctx context.Context, commits []CommitInfo) string {
+	data, err := os.ReadFile(gitPath(ctx, "shallow"))
+	if err != nil {
+		return ""
+	}
+	shallow := make(map[string]bool)
+	for _, h := range strings.Fields(string(data)) {
+		shallow[h] = true
+	}
+	for _, c := range commits {
+		if shallow[c.Hash] {
+			return c.ShortHash
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PublishedCommit is a commit in the range that is already reachable from
+// a remote-tracking ref or a protected branch.
+type PublishedCommit struct {
+	Commit CommitInfo
+	// Refs are the short names of the refs containing the commit.
+	Refs []string
+}
+
+// ProtectedRefPatterns returns the ref patterns whose commits count as
+// published: every remote-tracking ref, plus the local branches matched by
+// the retime.protectedBranches config (e.g. "main", "release/*").
+func ProtectedRefPatterns(ctx context.Context) []string {
+	patterns := []string{"refs/remotes/"}
+	out, err := exec.CommandContext(ctx, "git", "config", "--get-all", "retime.protectedBranches").Output()
+	if err != nil {
+		return patterns
+	}
+	for _, p := range strings.Fields(string(out)) {
+		patterns = append(patterns, "refs/heads/"+p)
+	}
+	return patterns
+}
+
+// FindPublished returns the commits that are reachable from a ref matching
+// one of the patterns, in range order.
+func FindPublished(ctx context.Context, commits []CommitInfo, base, tip string, patterns []string) ([]PublishedCommit, error) {
+	refs, err := forEachRef(ctx, patterns)
+	if err != nil || len(refs) == 0 {
+		return nil, err
+	}
+
+	// Anything not listed here is reachable from one of the refs.
+	args := []string{"rev-list", tip}
+	if base != "" {
+		args = append(args, "^"+base)
+	}
+	args = append(args, "--not")
+	args = append(args, refs...)
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("checking for published commits: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+	unpublished := make(map[string]bool)
+	for _, h := range strings.Fields(string(out)) {
+		unpublished[h] = true
+	}
+
+	var published []PublishedCommit
+	for _, c := range commits {
+		if unpublished[c.Hash] {
+			continue
+		}
+		containing, err := forEachRef(ctx, append([]string{"--contains=" + c.Hash}, patterns...))
+		if err != nil {
+			return nil, err
+		}
+		published = append(published, PublishedCommit{Commit: c, Refs: shortRefs(containing)})
+	}
+	return published, nil
+}
+
+// forEachRef lists the full ref names matching args.
+func forEachRef(ctx context.Context, args []string) ([]string, error) {
+	args = append([]string{"for-each-ref", "--format=%(refname)"}, args...)
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("listing refs: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return strings.Fields(string(out)), nil
+}
+
+func shortRefs(refs []string) []string {
+	short := make([]string, len(refs))
+	for i, r := range refs {
+		r = strings.TrimPrefix(r, "refs/remotes/")
+		short[i] = strings.TrimPrefix(r, "refs/heads/")
+	}
+	return short
+}
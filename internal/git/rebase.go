@@ -1,35 +1,214 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
+// RebaseOptions describes the rebase that replays the compiled todo.
+type RebaseOptions struct {
+	// Base is the exclusive base commit; ignored when NeedsRoot is set.
+	Base      string
+	NeedsRoot bool
+	Target    Target
+	// Autostash stashes local changes around an in-place rebase.
+	Autostash bool
+	// RunHooks keeps the repository's hooks active during the rebase. By
+	// default they are disabled, including in the commands it runs.
+	RunHooks bool
+	// NoAutoAbort leaves a failed rebase stopped, so it can be resolved
+	// and finished with ContinueRebase or undone with AbortRebase.
+	NoAutoAbort bool
+}
+
+// RebaseStoppedError reports a rebase that failed and was left stopped
+// because of NoAutoAbort.
+type RebaseStoppedError struct {
+	// Dir is the worktree the rebase stopped in, "" for the current one.
+	Dir string
+	Err error
+}
+
+func (e *RebaseStoppedError) Error() string {
+	return fmt.Sprintf("rebase stopped: %v", e.Err)
+}
+
+func (e *RebaseStoppedError) Unwrap() error {
+	return e.Err
+}
+
 // ExecuteRebase runs a headless git rebase -i, injecting the compiled todo
 // via GIT_SEQUENCE_EDITOR. The todoPath is the path to the compiled rebase
 // todo file that will replace the one git generates.
-func ExecuteRebase(todoPath, base string, needsRoot bool) error {
-	args := []string{"rebase", "-i", "--rebase-merges"}
-	if needsRoot {
+//
+// When the target is not the checked-out HEAD, the rebase runs on a
+// detached HEAD in a temporary worktree and the target ref is then moved
+// to the result, so the branch never has to be checked out.
+//
+// Cancelling ctx does not kill git halfway through a step. The rebase is
+// left to finish and then rolled back, or aborted if it failed, even with
+// NoAutoAbort, so the repository is never left mid-rebase and the target
+// is not moved.
+func ExecuteRebase(ctx context.Context, todoPath string, opts RebaseOptions) error {
+	target := opts.Target
+	if target.InPlace() {
+		err := runRebase(ctx, "", todoPath, opts)
+		if err == nil && ctx.Err() != nil {
+			return rollBack(ctx, target)
+		}
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "git-retime-worktree-*")
+	if err != nil {
+		return fmt.Errorf("creating temp worktree directory: %w", err)
+	}
+
+	var args []string
+	if !opts.RunHooks {
+		// "worktree add" checks out the tip and so runs post-checkout.
+		args = append(args, "-c", "core.hooksPath=/dev/null")
+	}
+	args = append(args, "worktree", "add", "--detach", dir, target.Tip)
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return fmt.Errorf("creating temp worktree: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	err = runRebase(ctx, dir, todoPath, opts)
+	var stopped *RebaseStoppedError
+	if errors.As(err, &stopped) {
+		// The worktree is where the user resolves the stopped rebase.
+		return err
+	}
+	defer removeWorktree(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("retime interrupted: %w\nhint: %s was left unchanged", ctx.Err(), target.Ref)
+	}
+	return moveTarget(ctx, dir, target)
+}
+
+// ContinueRebase resumes a rebase left stopped by NoAutoAbort in dir, as
+// reported by RebaseStoppedError, and moves the target to the result. If
+// the user already finished the rebase by hand, only the target is moved.
+// verify is given the resulting HEAD before that and can refuse it, for
+// example when the rebase was aborted by hand; the target then stays put.
+// A rebase that stops again is left stopped. Cancelling ctx lets git finish
+// first, as in ExecuteRebase, but the continued rebase is then kept: there
+// is no original state left to roll back to.
+func ContinueRebase(ctx context.Context, dir string, opts RebaseOptions, verify func(head string) error) error {
+	if rebaseInProgress(ctx, dir) {
+		var args []string
+		if !opts.RunHooks {
+			args = append(args, "-c", "core.hooksPath=/dev/null")
+		}
+		args = append(args, "rebase", "--continue")
+
+		if err := runGitRebase(ctx, dir, nil, args); err != nil {
+			return &RebaseStoppedError{Dir: dir, Err: err}
+		}
+	}
+	ctx = context.WithoutCancel(ctx)
+	head, err := rewrittenHead(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if err := verify(head); err != nil {
+		return err
+	}
+	if opts.Target.InPlace() {
+		return nil
+	}
+	defer removeWorktree(ctx, dir)
+	return moveTarget(ctx, dir, opts.Target)
+}
+
+// AbortRebase undoes a rebase left stopped by NoAutoAbort in dir.
+func AbortRebase(ctx context.Context, dir string, target Target) error {
+	if rebaseInProgress(ctx, dir) {
+		if err := abortRebase(ctx, dir); err != nil {
+			return fmt.Errorf("rebase --abort failed: %s", err)
+		}
+	}
+	if !target.InPlace() {
+		removeWorktree(ctx, dir)
+	}
+	return nil
+}
+
+// moveTarget points the target ref at the tip rewritten in the temporary
+// worktree dir.
+func moveTarget(ctx context.Context, dir string, target Target) error {
+	newTip, err := rewrittenHead(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	// The old value makes the update fail if the ref moved meanwhile; an
+	// empty old value requires a new branch not to exist yet.
+	oldValue := target.Tip
+	if target.Create {
+		oldValue = ""
+	}
+	return updateRef(ctx, target.Ref, newTip, oldValue)
+}
+
+// rewrittenHead reads HEAD in dir ("" for the current directory).
+func rewrittenHead(ctx context.Context, dir string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("reading rewritten tip: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runRebase runs the headless rebase in dir ("" for the current directory).
+func runRebase(ctx context.Context, dir, todoPath string, opts RebaseOptions) error {
+	var args []string
+	if !opts.RunHooks {
+		// Passed on to the exec'd commits through GIT_CONFIG_PARAMETERS, so
+		// prepare-commit-msg and friends stay quiet too.
+		args = append(args, "-c", "core.hooksPath=/dev/null")
+	}
+	args = append(args, "rebase", "-i", "--rebase-merges")
+	if opts.Autostash {
+		args = append(args, "--autostash")
+	}
+	if opts.NoAutoAbort {
+		// A failed amend is run again by --continue, so no commit is
+		// left with its old dates.
+		args = append(args, "--reschedule-failed-exec")
+	}
+	if opts.NeedsRoot {
 		args = append(args, "--root")
 	} else {
-		args = append(args, base)
+		args = append(args, opts.Base)
 	}
 
 	seqEditor := fmt.Sprintf("cp %q", todoPath)
 
-	cmd := exec.Command("git", args...)
-	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR="+seqEditor)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
+	err := runGitRebase(ctx, dir, []string{"GIT_SEQUENCE_EDITOR=" + seqEditor}, args)
+	if err != nil && ctx.Err() != nil {
+		if abortErr := abortRebase(context.WithoutCancel(ctx), dir); abortErr != nil {
+			return fmt.Errorf("rebase interrupted: %w\nadditionally, rebase --abort failed: %s", context.Canceled, abortErr)
+		}
+		return fmt.Errorf("rebase interrupted (aborted): %w\nhint: your repository has been restored to its original state", context.Canceled)
+	}
+	if err != nil && opts.NoAutoAbort {
+		return &RebaseStoppedError{Dir: dir, Err: err}
+	}
 	if err != nil {
 		// Attempt auto-abort.
-		abortErr := abortRebase()
+		abortErr := abortRebase(ctx, dir)
 		if abortErr != nil {
 			return fmt.Errorf("rebase failed: %w\nadditionally, rebase --abort failed: %s", err, abortErr)
 		}
@@ -38,17 +217,85 @@ func ExecuteRebase(todoPath, base string, needsRoot bool) error {
 	return nil
 }
 
-func abortRebase() error {
-	out, err := exec.Command("git", "rebase", "--abort").CombinedOutput()
+func abortRebase(ctx context.Context, dir string) error {
+	cmd := exec.CommandContext(ctx, "git", "rebase", "--abort")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
 	}
 	return nil
 }
 
+// runGitRebase runs a git rebase command in dir, with env added to the
+// current environment.
+//
+// git gets a process group of its own, so a Ctrl-C meant for git-retime
+// cannot kill it halfway through a step and strand its lock files; the
+// caller deals with the cancelled ctx once git is done. For the same
+// reason the rebase does not read the terminal, and so must not open an
+// editor either: the compiled todo sets every message already, including
+// those of merges git would ask about after a resolved conflict.
+func runGitRebase(ctx context.Context, dir string, env, args []string) error {
+	cmd := exec.Command("git", args...)
+	ownProcessGroup(cmd)
+	cmd.Dir = dir
+	cmd.Env = append(append(os.Environ(), "GIT_EDITOR=:"), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "interrupted, waiting for git to finish the rebase...")
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
+
+// rollBack resets HEAD to the original tip after a rebase that finished in
+// place although the retime was interrupted. Local changes are kept.
+func rollBack(ctx context.Context, target Target) error {
+	ctx = context.WithoutCancel(ctx)
+	out, err := exec.CommandContext(ctx, "git", "reset", "--keep", "-q", target.Tip).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("retime interrupted, but resetting back to %.7s failed: %s\n%s", target.Tip, err, strings.TrimSpace(string(out)))
+	}
+	return fmt.Errorf("retime interrupted (rolled back): %w\nhint: your repository has been restored to its original state", context.Canceled)
+}
+
+func removeWorktree(ctx context.Context, dir string) {
+	// The worktree is cleaned up even after cancellation.
+	ctx = context.WithoutCancel(ctx)
+	exec.CommandContext(ctx, "git", "worktree", "remove", "--force", dir).Run()
+	os.RemoveAll(dir)
+}
+
+// rebaseInProgress reports whether a rebase is stopped in dir ("" for the
+// current directory).
+func rebaseInProgress(ctx context.Context, dir string) bool {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", "rebase-merge")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return pathExists(path)
+}
+
 // HasMergeCommits checks whether the given range contains merge commits.
-func HasMergeCommits(revision string) (bool, error) {
-	out, err := exec.Command("git", "log", "--merges", "--oneline", revision+"..HEAD").CombinedOutput()
+func HasMergeCommits(ctx context.Context, revision string) (bool, error) {
+	out, err := exec.CommandContext(ctx, "git", "log", "--merges", "--oneline", revision+"..HEAD").CombinedOutput()
 	if err != nil {
 		return false, fmt.Errorf("checking for merges: %s", err)
 	}
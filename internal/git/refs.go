@@ -0,0 +1,224 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadCommitMap parses an old->new commit map with one
+// "<old-hash> <new-hash>" pair per line.
+func ReadCommitMap(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading commit map: %w", err)
+	}
+	defer f.Close()
+
+	mapping := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		mapping[fields[0]] = fields[1]
+	}
+	return mapping, scanner.Err()
+}
+
+// UpdateBranches moves every local branch that points at a rewritten commit
+// to its replacement and returns the names of the branches it moved.
+func UpdateBranches(ctx context.Context, mapping map[string]string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname) %(objectname)", "refs/heads/").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	var updated []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		ref, old, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		newHash, rewritten := mapping[old]
+		if !rewritten {
+			continue
+		}
+		if err := updateRef(ctx, ref, newHash, old); err != nil {
+			return updated, err
+		}
+		updated = append(updated, strings.TrimPrefix(ref, "refs/heads/"))
+	}
+	return updated, nil
+}
+
+// TagUpdate reports a tag moved by UpdateTags.
+type TagUpdate struct {
+	Name string
+	// DroppedSignature is set when a signed annotated tag had to be
+	// recreated without its signature.
+	DroppedSignature bool
+}
+
+// UpdateTags moves every tag that points at a rewritten commit. Lightweight
+// tags are simply moved; annotated tags are recreated with the same name,
+// tagger and message. When taggerShifts has an entry for the old commit,
+// the tagger date is moved by that amount.
+func UpdateTags(ctx context.Context, mapping map[string]string, taggerShifts map[string]time.Duration) ([]TagUpdate, error) {
+	out, err := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname) %(objecttype) %(objectname) %(*objectname)", "refs/tags/").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	var updated []TagUpdate
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		ref, objType, object := fields[0], fields[1], fields[2]
+		name := strings.TrimPrefix(ref, "refs/tags/")
+
+		switch objType {
+		case "commit":
+			newHash, rewritten := mapping[object]
+			if !rewritten {
+				continue
+			}
+			if err := updateRef(ctx, ref, newHash, object); err != nil {
+				return updated, err
+			}
+			updated = append(updated, TagUpdate{Name: name})
+
+		case "tag":
+			if len(fields) < 4 {
+				continue
+			}
+			peeled := fields[3]
+			newHash, rewritten := mapping[peeled]
+			if !rewritten {
+				continue
+			}
+			shift := taggerShifts[peeled]
+			newTag, dropped, err := recreateTag(ctx, ref, object, newHash, "", func(t time.Time) time.Time { return t.Add(shift) })
+			if err != nil {
+				return updated, fmt.Errorf("recreating tag %s: %w", name, err)
+			}
+			if err := updateRef(ctx, ref, newTag, object); err != nil {
+				return updated, err
+			}
+			updated = append(updated, TagUpdate{Name: name, DroppedSignature: dropped})
+		}
+	}
+	return updated, nil
+}
+
+// recreateTag writes a copy of an annotated tag object pointing at target,
+// with its tagger date passed through retime. A non-empty subject replaces
+// the first paragraph of the message. A signature cannot survive the
+// change, so it is dropped and reported. ref names the tag, for reading the
+// signature.
+func recreateTag(ctx context.Context, ref, tagObject, target, subject string, retime func(time.Time) time.Time) (newTag string, droppedSignature bool, err error) {
+	out, err := exec.CommandContext(ctx, "git", "cat-file", "tag", tagObject).Output()
+	if err != nil {
+		return "", false, fmt.Errorf("reading tag object: %w", err)
+	}
+
+	header, message, _ := strings.Cut(string(out), "\n\n")
+	var lines []string
+	for _, line := range strings.Split(header, "\n") {
+		switch {
+		case strings.HasPrefix(line, "object "):
+			line = "object " + target
+		case strings.HasPrefix(line, "tagger "):
+			ident, date, err := parseIdentDate(line)
+			if err != nil {
+				return "", false, err
+			}
+			line = ident + formatIdentDate(retime(date))
+		}
+		lines = append(lines, line)
+	}
+
+	// Only the trailing signature block goes; the message itself may well
+	// quote a key or a certificate.
+	signature, err := tagSignature(ctx, ref, tagObject)
+	if err != nil {
+		return "", false, err
+	}
+	if signature != "" && strings.HasSuffix(message, signature) {
+		message = strings.TrimSuffix(message, signature)
+		droppedSignature = true
+	}
+	if subject != "" {
+		_, rest, found := strings.Cut(message, "\n\n")
+		message = subject + "\n"
+		if found {
+			message += "\n" + rest
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "mktag")
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n\n" + message)
+	newOut, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", false, fmt.Errorf("git mktag: %s\n%s", err, strings.TrimSpace(string(newOut)))
+	}
+	return strings.TrimSpace(string(newOut)), droppedSignature, nil
+}
+
+// tagSignature returns the signature block at the end of the tag object
+// tagObject, which ref points at, or "" when it is not signed.
+func tagSignature(ctx context.Context, ref, tagObject string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(objectname)%00%(contents:signature)%00", ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("reading signature of %s: %w", ref, err)
+	}
+	// The pattern also matches refs below ref, so pick the record by object.
+	for _, record := range strings.Split(string(out), "\x00\n") {
+		object, signature, _ := strings.Cut(record, "\x00")
+		if object == tagObject {
+			return signature, nil
+		}
+	}
+	return "", nil
+}
+
+// parseIdentDate splits an ident line such as
+// "tagger Name <email> 1700000000 +0100" into the part before the date
+// (including the trailing space) and the date in its recorded offset.
+func parseIdentDate(line string) (ident string, date time.Time, err error) {
+	offsetIdx := strings.LastIndex(line, " ")
+	secsIdx := strings.LastIndex(line[:max(offsetIdx, 0)], " ")
+	if secsIdx < 0 {
+		return "", time.Time{}, fmt.Errorf("malformed ident line %q", line)
+	}
+	secs, err := strconv.ParseInt(line[secsIdx+1:offsetIdx], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed ident date in %q", line)
+	}
+	offset, err := time.Parse("-0700", line[offsetIdx+1:])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed ident offset in %q", line)
+	}
+	return line[:secsIdx+1], time.Unix(secs, 0).In(offset.Location()), nil
+}
+
+// formatIdentDate renders t the way git stores it in an ident line.
+func formatIdentDate(t time.Time) string {
+	return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700"))
+}
+
+func updateRef(ctx context.Context, ref, newValue, oldValue string) error {
+	out, err := exec.CommandContext(ctx, "git", "update-ref", "-m", "git-retime", ref, newValue, oldValue).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("updating %s: %s\n%s", ref, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
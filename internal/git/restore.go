@@ -0,0 +1,299 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OriginalDates are the dates a commit had before it was retimed.
+type OriginalDates struct {
+	AuthorDate time.Time
+	CommitDate time.Time
+	// Source is the old commit the dates were taken from, or "audit note".
+	Source string
+}
+
+// FindOriginalDates looks up the original dates of commits that were
+// retimed earlier, even if more commits and rebases have happened since.
+//
+// The audit notes written by --audit are used when present. Otherwise the
+// commit is matched by patch-id against the old commits still reachable
+// from the given reflogs; when several old versions match, the one that
+// appeared first in the reflogs wins. Commits with no differing original
+// are left out of the result.
+func FindOriginalDates(ctx context.Context, commits []CommitInfo, tip string, reflogs []string) (map[string]OriginalDates, error) {
+	found := make(map[string]OriginalDates)
+
+	var rest []CommitInfo
+	for _, c := range commits {
+		if d, ok := auditedDates(ctx, c.Hash); ok {
+			found[c.Hash] = d
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	if len(rest) == 0 {
+		return found, nil
+	}
+
+	candidates, err := reflogCommits(ctx, tip, reflogs)
+	if err != nil {
+		return nil, err
+	}
+	matched, err := matchByPatchID(ctx, rest, candidates, func(c CommitInfo, d OriginalDates) bool {
+		return !d.AuthorDate.Equal(c.AuthorDate) || !d.CommitDate.Equal(c.CommitDate)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range rest {
+		d, ok := matched[c.Hash]
+		if !ok {
+			continue
+		}
+		// The old version may have been retimed itself; its audit note
+		// then holds the dates from before that.
+		if audited, ok := auditedDates(ctx, d.Source); ok {
+			if audited.AuthorDate.Equal(c.AuthorDate) && audited.CommitDate.Equal(c.CommitDate) {
+				continue
+			}
+			d = audited
+		}
+		found[c.Hash] = d
+	}
+	return found, nil
+}
+
+// FindPreRebaseDates matches commits to their versions from before an
+// ordinary rebase, to recover the committer dates the rebase reset. The
+// commits reachable from origHead (usually ORIG_HEAD) are tried first, then
+// the old commits in the given reflogs. Only versions with a different
+// committer date count as a match.
+func FindPreRebaseDates(ctx context.Context, commits []CommitInfo, tip, origHead string, reflogs []string) (map[string]OriginalDates, error) {
+	var candidates []string
+	if origHead != "" {
+		resolved, err := ResolveRevision(ctx, origHead)
+		if err != nil {
+			return nil, err
+		}
+		out, err := exec.CommandContext(ctx, "git", "rev-list", resolved, "^"+tip).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("listing commits of %s: %s\n%s", origHead, err, strings.TrimSpace(string(out)))
+		}
+		candidates = strings.Fields(string(out))
+	}
+
+	more, err := reflogCommits(ctx, tip, reflogs)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, more...)
+
+	return matchByPatchID(ctx, commits, candidates, func(c CommitInfo, d OriginalDates) bool {
+		return !d.CommitDate.Equal(c.CommitDate)
+	})
+}
+
+// matchByPatchID pairs each commit with the first candidate that has the
+// same patch-id and for which differs reports a change, and returns the
+// candidates' dates keyed by commit hash.
+func matchByPatchID(ctx context.Context, commits []CommitInfo, candidates []string, differs func(CommitInfo, OriginalDates) bool) (map[string]OriginalDates, error) {
+	found := make(map[string]OriginalDates)
+	if len(commits) == 0 || len(candidates) == 0 {
+		return found, nil
+	}
+
+	hashes := make([]string, 0, len(commits)+len(candidates))
+	for _, c := range commits {
+		hashes = append(hashes, c.Hash)
+	}
+	hashes = append(hashes, candidates...)
+	ids, err := patchIDs(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	dates, err := commitDates(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range commits {
+		id, ok := ids[c.Hash]
+		if !ok {
+			continue
+		}
+		for _, old := range candidates {
+			d := dates[old]
+			if ids[old] != id || !differs(c, d) {
+				continue
+			}
+			d.Source = old
+			found[c.Hash] = d
+			break
+		}
+	}
+	return found, nil
+}
+
+// auditedDates reads the first paragraph of a commit's audit note, which
+// holds the dates from before its first retime.
+func auditedDates(ctx context.Context, hash string) (OriginalDates, bool) {
+	out, err := exec.CommandContext(ctx, "git", "notes", "--ref="+AuditNotesRef, "show", hash).Output()
+	if err != nil {
+		return OriginalDates{}, false
+	}
+	first, _, _ := strings.Cut(string(out), "\n\n")
+
+	var d OriginalDates
+	for _, line := range strings.Split(first, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "Original-Author-Date":
+			d.AuthorDate = t
+		case "Original-Committer-Date":
+			d.CommitDate = t
+		}
+	}
+	if d.AuthorDate.IsZero() || d.CommitDate.IsZero() {
+		return OriginalDates{}, false
+	}
+	d.Source = "audit note"
+	return d, true
+}
+
+// reflogCommits lists the commits reachable from the reflog entries of refs
+// but not from tip, in the order they first appeared.
+func reflogCommits(ctx context.Context, tip string, refs []string) ([]string, error) {
+	var entries []string
+	seen := map[string]bool{tip: true}
+	for _, ref := range refs {
+		out, err := exec.CommandContext(ctx, "git", "reflog", "show", "--format=%H", ref, "--").Output()
+		if err != nil {
+			// A ref without a reflog has nothing to offer.
+			continue
+		}
+		fields := strings.Fields(string(out))
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !seen[fields[i]] {
+				seen[fields[i]] = true
+				entries = append(entries, fields[i])
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	// A single walk covers every entry; the parents tell which entry
+	// brought in which commit.
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--reverse", "--parents", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(entries, "\n") + "\n^" + tip + "\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("walking reflogs: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+	var order []string
+	parents := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		order = append(order, fields[0])
+		parents[fields[0]] = fields[1:]
+	}
+
+	// Each commit belongs to the oldest entry that reaches it, as if the
+	// entries were walked one by one, each excluding those before it.
+	owner := make(map[string]int)
+	for i, entry := range entries {
+		stack := []string{entry}
+		for len(stack) > 0 {
+			h := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if _, listed := parents[h]; !listed {
+				continue // reachable from tip
+			}
+			if _, taken := owner[h]; taken {
+				continue
+			}
+			owner[h] = i
+			stack = append(stack, parents[h]...)
+		}
+	}
+	groups := make([][]string, len(entries))
+	for _, h := range order {
+		groups[owner[h]] = append(groups[owner[h]], h)
+	}
+	result := make([]string, 0, len(order))
+	for _, g := range groups {
+		result = append(result, g...)
+	}
+	return result, nil
+}
+
+// patchIDs returns the stable patch-id of each commit that has a diff.
+func patchIDs(ctx context.Context, hashes []string) (map[string]string, error) {
+	diff := exec.CommandContext(ctx, "git", "diff-tree", "--stdin", "-p", "--root")
+	diff.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	patches, err := diff.Output()
+	if err != nil {
+		return nil, fmt.Errorf("computing diffs: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "patch-id", "--stable")
+	cmd.Stdin = strings.NewReader(string(patches))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("computing patch-ids: %w", err)
+	}
+
+	ids := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			ids[fields[1]] = fields[0]
+		}
+	}
+	return ids, nil
+}
+
+// commitDates reads the author and committer dates of the given commits.
+func commitDates(ctx context.Context, hashes []string) (map[string]OriginalDates, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "--no-walk=unsorted", "--stdin", "--format=%H %aI %cI")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("reading commit dates: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	dates := make(map[string]OriginalDates)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		author, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing author date %q: %w", fields[1], err)
+		}
+		committer, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("parsing commit date %q: %w", fields[2], err)
+		}
+		dates[fields[0]] = OriginalDates{AuthorDate: author, CommitDate: committer}
+	}
+	return dates, nil
+}
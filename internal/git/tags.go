@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// TagInfo holds the metadata of an annotated tag needed for retiming.
+type TagInfo struct {
+	Name string
+	// Object is the tag object itself; Target is the object it points at.
+	Object     string
+	Target     string
+	TaggerDate time.Time
+	Subject    string
+	Signed     bool
+}
+
+// ListAnnotatedTags returns the annotated tags whose names match pattern
+// (a for-each-ref glob such as "v1.*"), oldest tagger date first. An empty
+// pattern lists every tag, including hierarchical ones like "release/v1".
+// Lightweight tags have no date of their own and are skipped.
+func ListAnnotatedTags(ctx context.Context, pattern string) ([]TagInfo, error) {
+	format := strings.Join([]string{
+		"%(refname:strip=2)",
+		"%(objecttype)",
+		"%(objectname)",
+		"%(object)",
+		"%(taggerdate:iso-strict)",
+		"%(if)%(contents:signature)%(then)signed%(end)",
+		"%(contents:subject)",
+	}, "%00")
+	out, err := exec.CommandContext(ctx, "git", "for-each-ref", "--sort=taggerdate", "--format="+format, "refs/tags/"+pattern).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %s\n%s", err, strings.TrimSpace(string(out)))
+	}
+
+	var tags []TagInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 7)
+		if len(fields) != 7 || fields[1] != "tag" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("parsing tagger date of %s: %w", fields[0], err)
+		}
+		tags = append(tags, TagInfo{
+			Name:       fields[0],
+			Object:     fields[2],
+			Target:     fields[3],
+			TaggerDate: date,
+			Signed:     fields[5] != "",
+			Subject:    fields[6],
+		})
+	}
+	return tags, nil
+}
+
+// RetimeTag recreates an annotated tag with a new tagger date and, when
+// subject is non-empty, a new subject. The tag keeps its name, tagger and
+// target. It reports whether a signature had to be dropped.
+func RetimeTag(ctx context.Context, tag TagInfo, date time.Time, subject string) (droppedSignature bool, err error) {
+	newTag, dropped, err := recreateTag(ctx, "refs/tags/"+tag.Name, tag.Object, tag.Target, subject, func(time.Time) time.Time { return date })
+	if err != nil {
+		return false, fmt.Errorf("recreating tag %s: %w", tag.Name, err)
+	}
+	if err := updateRef(ctx, "refs/tags/"+tag.Name, newTag, tag.Object); err != nil {
+		return false, err
+	}
+	return dropped, nil
+}
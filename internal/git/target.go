@@ -0,0 +1,87 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Target describes where the rewritten commits end up.
+type Target struct {
+	// Tip is the resolved commit the range ends at.
+	Tip string
+	// Ref is the ref to point at the rewritten tip (e.g. refs/heads/topic).
+	// Empty means the checked-out HEAD is rebased in place.
+	Ref string
+	// Create requires Ref not to exist yet, for --output-branch.
+	Create bool
+}
+
+// InPlace reports whether the rewrite happens on the checked-out HEAD.
+func (t Target) InPlace() bool {
+	return t.Ref == ""
+}
+
+// ResolveTarget works out where a retime of tipRev should be written.
+//
+// The checked-out branch (or HEAD itself) is rebased in place as before,
+// except in a bare repository, which has nothing checked out.
+// Any other local branch is rewritten without checking it out. When
+// outputBranch is set, the result goes to that new branch instead and the
+// original is left untouched.
+func ResolveTarget(ctx context.Context, tipRev, outputBranch string) (Target, error) {
+	tip, err := ResolveRevision(ctx, tipRev)
+	if err != nil {
+		return Target{}, err
+	}
+
+	if outputBranch != "" {
+		ref := "refs/heads/" + outputBranch
+		if err := exec.CommandContext(ctx, "git", "check-ref-format", ref).Run(); err != nil {
+			return Target{}, fmt.Errorf("invalid branch name %q", outputBranch)
+		}
+		return Target{Tip: tip, Ref: ref, Create: true}, nil
+	}
+
+	if tipRev == "HEAD" {
+		// A bare repository has no checkout to rebase in place, so its
+		// HEAD branch is rewritten like any other branch.
+		if IsBare(ctx) {
+			ref := currentBranch(ctx)
+			if ref == "" {
+				return Target{}, errors.New("HEAD is detached; use --output-branch to store the retimed commits")
+			}
+			return Target{Tip: tip, Ref: ref}, nil
+		}
+		return Target{Tip: tip}, nil
+	}
+
+	ref := symbolicFullName(ctx, tipRev)
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return Target{}, fmt.Errorf("%s is not a local branch; use --output-branch to store the retimed commits", tipRev)
+	}
+	if ref == currentBranch(ctx) && !IsBare(ctx) {
+		return Target{Tip: tip}, nil
+	}
+	return Target{Tip: tip, Ref: ref}, nil
+}
+
+func symbolicFullName(ctx context.Context, rev string) string {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--symbolic-full-name", rev).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// currentBranch returns the full ref of the checked-out branch, or "" when
+// HEAD is detached.
+func currentBranch(ctx context.Context) string {
+	out, err := exec.CommandContext(ctx, "git", "symbolic-ref", "-q", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
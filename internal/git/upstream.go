@@ -0,0 +1,38 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DefaultBase picks the base revision when none is given: the merge-base of
+// tipRev with the retime.defaultBase config value if set, otherwise with
+// tipRev's upstream. The result covers exactly the commits that are not on
+// that branch yet. from names the branch used, for messages.
+func DefaultBase(ctx context.Context, tipRev string) (base, from string, err error) {
+	from = configValue(ctx, "retime.defaultBase")
+	if from == "" {
+		out, err := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", tipRev+"@{upstream}").Output()
+		if err != nil {
+			return "", "", fmt.Errorf("no revision given and %s has no upstream\nhint: pass a revision, set an upstream, or set retime.defaultBase (e.g. git config retime.defaultBase main)", tipRev)
+		}
+		from = strings.TrimSpace(string(out))
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "merge-base", from, tipRev).CombinedOutput()
+	if err != nil {
+		return "", "", fmt.Errorf("cannot find merge-base of %s and %s: %s\n%s", from, tipRev, err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), from, nil
+}
+
+// configValue returns a git config value, or "" when it is unset.
+func configValue(ctx context.Context, key string) string {
+	out, err := exec.CommandContext(ctx, "git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
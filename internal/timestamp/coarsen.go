@@ -0,0 +1,22 @@
+package timestamp
+
+import "time"
+
+// Coarsen rounds every resolved date down to unit on loc's wall clock and
+// expresses it in loc, discarding the original timezone offset. With a
+// zero unit the dates are only converted to loc.
+//
+// This hides when and where commits were made: "--coarsen 1d --tz UTC"
+// leaves only the UTC calendar day.
+func Coarsen(commits []Commit, unit time.Duration, loc *time.Location) {
+	r := Rounding{Unit: unit, Mode: RoundFloor}
+	for i := range commits {
+		c := &commits[i]
+		c.ResolvedAuthorDate = c.ResolvedAuthorDate.In(loc)
+		c.ResolvedCommitDate = c.ResolvedCommitDate.In(loc)
+		if unit > 0 {
+			c.ResolvedAuthorDate = r.applyIn(c.ResolvedAuthorDate, loc)
+			c.ResolvedCommitDate = r.applyIn(c.ResolvedCommitDate, loc)
+		}
+	}
+}
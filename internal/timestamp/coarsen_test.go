@@ -0,0 +1,52 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoarsen_Day(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	// 2026-02-23 03:00 IST is still 2026-02-22 in UTC.
+	in := time.Date(2026, 2, 23, 3, 0, 0, 0, loc)
+	commits := []Commit{{ResolvedAuthorDate: in, ResolvedCommitDate: in.Add(time.Hour)}}
+
+	Coarsen(commits, 24*time.Hour, time.UTC)
+
+	want := time.Date(2026, 2, 22, 0, 0, 0, 0, time.UTC)
+	for _, got := range []time.Time{commits[0].ResolvedAuthorDate, commits[0].ResolvedCommitDate} {
+		if !got.Equal(want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if _, offset := got.Zone(); offset != 0 {
+			t.Errorf("expected UTC offset, got %d", offset)
+		}
+	}
+}
+
+func TestCoarsen_Hour(t *testing.T) {
+	in := time.Date(2026, 2, 23, 10, 47, 13, 0, time.UTC)
+	commits := []Commit{{ResolvedAuthorDate: in, ResolvedCommitDate: in}}
+
+	Coarsen(commits, time.Hour, time.UTC)
+
+	want := time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC)
+	if !commits[0].ResolvedAuthorDate.Equal(want) {
+		t.Errorf("expected %v, got %v", want, commits[0].ResolvedAuthorDate)
+	}
+}
+
+func TestCoarsen_ZoneOnly(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	in := time.Date(2026, 2, 23, 10, 47, 13, 0, loc)
+	commits := []Commit{{ResolvedAuthorDate: in, ResolvedCommitDate: in}}
+
+	Coarsen(commits, 0, time.UTC)
+
+	if !commits[0].ResolvedAuthorDate.Equal(in) {
+		t.Errorf("instant changed: %v", commits[0].ResolvedAuthorDate)
+	}
+	if _, offset := commits[0].ResolvedAuthorDate.Zone(); offset != 0 {
+		t.Errorf("expected UTC offset, got %d", offset)
+	}
+}
@@ -0,0 +1,45 @@
+package timestamp
+
+import "time"
+
+// EnforceMinGap pushes commits forward so that each resolved date is at
+// least gap after the previous commit's resolved date. Author and committer
+// dates are handled independently. Offenders move by the smallest amount
+// that satisfies the gap, and the push cascades down the list.
+//
+// A commit deliberately placed before its predecessor (a time paradox) is
+// left where it is; only commits that are tied with or just after their
+// predecessor are moved.
+//
+// movable marks the commits that may be pushed, nil meaning all of them.
+// The others keep their dates but still count as predecessors.
+func EnforceMinGap(commits []Commit, gap time.Duration, movable []bool) {
+	if len(commits) == 0 {
+		return
+	}
+	// Paradoxes are judged against where the predecessor was before it got
+	// pushed, so a cascade does not mistake tied commits for paradoxes.
+	origAuthor := commits[0].ResolvedAuthorDate
+	origCommit := commits[0].ResolvedCommitDate
+	for i := 1; i < len(commits); i++ {
+		prev := commits[i-1]
+		curr := &commits[i]
+		nextAuthor, nextCommit := curr.ResolvedAuthorDate, curr.ResolvedCommitDate
+		if movable != nil && !movable[i] {
+			origAuthor, origCommit = nextAuthor, nextCommit
+			continue
+		}
+		curr.ResolvedAuthorDate = pushAfter(curr.ResolvedAuthorDate, origAuthor, prev.ResolvedAuthorDate, gap)
+		curr.ResolvedCommitDate = pushAfter(curr.ResolvedCommitDate, origCommit, prev.ResolvedCommitDate, gap)
+		origAuthor, origCommit = nextAuthor, nextCommit
+	}
+}
+
+// pushAfter moves t to prev+gap when it is not a paradox relative to
+// origPrev and sits closer than gap to prev.
+func pushAfter(t, origPrev, prev time.Time, gap time.Duration) time.Time {
+	if t.Before(origPrev) || !t.Before(prev.Add(gap)) {
+		return t
+	}
+	return t.Add(prev.Add(gap).Sub(t))
+}
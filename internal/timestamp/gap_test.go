@@ -0,0 +1,76 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnforceMinGap(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	at := func(d time.Duration) Commit {
+		return Commit{ResolvedAuthorDate: base.Add(d), ResolvedCommitDate: base.Add(d)}
+	}
+
+	commits := []Commit{
+		at(0),
+		at(0),                // duplicate -> 10:03
+		at(0),                // duplicate, cascades -> 10:06
+		at(7 * time.Minute),  // too close to pushed 10:06 -> 10:09
+		at(30 * time.Minute), // far enough, untouched
+		at(-time.Hour),       // paradox, left alone
+	}
+
+	EnforceMinGap(commits, 3*time.Minute, nil)
+
+	want := []time.Duration{0, 3 * time.Minute, 6 * time.Minute, 9 * time.Minute, 30 * time.Minute, -time.Hour}
+	for i, w := range want {
+		if !commits[i].ResolvedAuthorDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d author: expected %v, got %v", i, base.Add(w), commits[i].ResolvedAuthorDate)
+		}
+		if !commits[i].ResolvedCommitDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d committer: expected %v, got %v", i, base.Add(w), commits[i].ResolvedCommitDate)
+		}
+	}
+}
+
+func TestEnforceMinGap_PreservesOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	ts := time.Date(2026, 2, 23, 10, 0, 0, 0, loc)
+	commits := []Commit{
+		{ResolvedAuthorDate: ts, ResolvedCommitDate: ts},
+		{ResolvedAuthorDate: ts, ResolvedCommitDate: ts},
+	}
+
+	EnforceMinGap(commits, time.Second, nil)
+
+	if _, offset := commits[1].ResolvedAuthorDate.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("timezone offset changed: got %d", offset)
+	}
+	if commits[1].ResolvedAuthorDate.Sub(ts) != time.Second {
+		t.Errorf("expected 1s push, got %v", commits[1].ResolvedAuthorDate.Sub(ts))
+	}
+}
+
+func TestEnforceMinGap_Movable(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) Commit {
+		return Commit{ResolvedAuthorDate: base.Add(d), ResolvedCommitDate: base.Add(d)}
+	}
+
+	commits := []Commit{
+		at(0),
+		at(time.Minute),     // fixed, too close but kept
+		at(time.Minute),     // movable, pushed past the fixed one -> 10:04
+		at(5 * time.Minute), // fixed, kept even though now too close
+		at(5 * time.Minute), // movable -> 10:08
+	}
+
+	EnforceMinGap(commits, 3*time.Minute, []bool{true, false, true, false, true})
+
+	want := []time.Duration{0, time.Minute, 4 * time.Minute, 5 * time.Minute, 8 * time.Minute}
+	for i, w := range want {
+		if !commits[i].ResolvedAuthorDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d: expected %v, got %v", i, base.Add(w), commits[i].ResolvedAuthorDate)
+		}
+	}
+}
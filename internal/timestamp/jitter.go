@@ -0,0 +1,43 @@
+package timestamp
+
+import "time"
+
+// Jitter moves every commit by a random offset in [-amount, +amount]
+// without letting it cross its neighbors: each commit stays within the
+// halfway points to the commits before and after it. The same offset is
+// applied to the author and committer date, so their relationship is kept.
+//
+// Neighbors that are already out of order (time paradoxes) do not bound
+// each other.
+//
+// movable marks the commits that may be moved, nil meaning all of them.
+// The others keep their dates but still bound their neighbors.
+func Jitter(commits []Commit, amount time.Duration, movable []bool) {
+	if amount <= 0 {
+		return
+	}
+
+	orig := make([]time.Time, len(commits))
+	for i, c := range commits {
+		orig[i] = c.ResolvedAuthorDate
+	}
+
+	for i := range commits {
+		if movable != nil && !movable[i] {
+			continue
+		}
+		lo, hi := -amount, amount
+		if i > 0 && !orig[i].Before(orig[i-1]) {
+			lo = max(lo, -orig[i].Sub(orig[i-1])/2)
+		}
+		if i < len(commits)-1 && !orig[i+1].Before(orig[i]) {
+			hi = min(hi, orig[i+1].Sub(orig[i])/2)
+		}
+
+		// Git stores whole seconds, so jitter in whole seconds too.
+		lo, hi = lo.Truncate(time.Second), hi.Truncate(time.Second)
+		offset := lo + time.Duration(rng.Int64N(int64((hi-lo)/time.Second)+1))*time.Second
+		commits[i].ResolvedAuthorDate = commits[i].ResolvedAuthorDate.Add(offset)
+		commits[i].ResolvedCommitDate = commits[i].ResolvedCommitDate.Add(offset)
+	}
+}
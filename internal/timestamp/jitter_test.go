@@ -0,0 +1,86 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter_BoundedAndOrdered(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	offsets := []time.Duration{0, 2 * time.Minute, 3 * time.Minute, time.Hour, 2 * time.Hour}
+
+	for run := 0; run < 50; run++ {
+		commits := planAt(base, offsets...)
+		Jitter(commits, 10*time.Minute, nil)
+
+		for i, c := range commits {
+			d := c.ResolvedAuthorDate.Sub(base.Add(offsets[i]))
+			if d < -10*time.Minute || d > 10*time.Minute {
+				t.Fatalf("commit %d moved by %v, more than 10m", i, d)
+			}
+			if d%time.Second != 0 {
+				t.Fatalf("commit %d moved by %v, not whole seconds", i, d)
+			}
+			if !c.ResolvedCommitDate.Equal(c.ResolvedAuthorDate) {
+				t.Fatalf("commit %d: committer date %v differs from author date %v", i, c.ResolvedCommitDate, c.ResolvedAuthorDate)
+			}
+			if i > 0 && c.ResolvedAuthorDate.Before(commits[i-1].ResolvedAuthorDate) {
+				t.Fatalf("commit %d reordered before commit %d", i, i-1)
+			}
+		}
+	}
+}
+
+func TestJitter_ParadoxNeighborsDoNotBound(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+
+	// The first commit may move later and the second earlier, since the
+	// pair is already out of order.
+	moved := false
+	for run := 0; run < 50 && !moved; run++ {
+		commits := planAt(base, time.Hour, 0)
+		Jitter(commits, 10*time.Minute, nil)
+		moved = commits[0].ResolvedAuthorDate.After(base.Add(time.Hour)) || commits[1].ResolvedAuthorDate.Before(base)
+	}
+	if !moved {
+		t.Error("expected paradox neighbors to move freely")
+	}
+}
+
+func TestJitter_Movable(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	offsets := []time.Duration{0, time.Minute, 2 * time.Minute}
+	movable := []bool{false, true, false}
+
+	for run := 0; run < 50; run++ {
+		commits := planAt(base, offsets...)
+		Jitter(commits, time.Hour, movable)
+
+		for i, c := range commits {
+			if !movable[i] && !c.ResolvedAuthorDate.Equal(base.Add(offsets[i])) {
+				t.Fatalf("commit %d is not movable but moved to %v", i, c.ResolvedAuthorDate)
+			}
+		}
+		if mid := commits[1].ResolvedAuthorDate; mid.Before(commits[0].ResolvedAuthorDate) || mid.After(commits[2].ResolvedAuthorDate) {
+			t.Fatalf("movable commit jumped past a fixed neighbor: %v", mid)
+		}
+	}
+}
+
+func TestJitter_Seed(t *testing.T) {
+	base := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+
+	Seed(42)
+	a := planAt(base, 0, time.Hour, 2*time.Hour)
+	Jitter(a, 10*time.Minute, nil)
+
+	Seed(42)
+	b := planAt(base, 0, time.Hour, 2*time.Hour)
+	Jitter(b, 10*time.Minute, nil)
+
+	for i := range a {
+		if !a[i].ResolvedAuthorDate.Equal(b[i].ResolvedAuthorDate) {
+			t.Errorf("commit %d: same seed gave %v and %v", i, a[i].ResolvedAuthorDate, b[i].ResolvedAuthorDate)
+		}
+	}
+}
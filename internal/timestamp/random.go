@@ -6,10 +6,20 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var rrPattern = regexp.MustCompile(`RR(?:\((\d+),(\d+)\))?`)
 
+// rng is the source of every random choice (RR tokens, randomized times of
+// day, jitter). It is freshly seeded per run unless Seed is called.
+var rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+
+// Seed makes all subsequent random choices deterministic.
+func Seed(seed uint64) {
+	rng = rand.New(rand.NewPCG(seed, seed))
+}
+
 // field position context for bare RR defaults
 type fieldKind int
 
@@ -82,7 +92,7 @@ func resolveField(field string, kind fieldKind) (string, error) {
 		return "", fmt.Errorf("RR min (%d) > max (%d)", lo, hi)
 	}
 
-	val := lo + rand.IntN(hi-lo+1)
+	val := lo + rng.IntN(hi-lo+1)
 	replacement := fmt.Sprintf("%02d", val)
 	return field[:loc[0]] + replacement + field[loc[1]:], nil
 }
@@ -91,3 +101,17 @@ func resolveField(field string, kind fieldKind) (string, error) {
 func ContainsRR(s string) bool {
 	return rrPattern.MatchString(s)
 }
+
+// RandomTimeOfDay keeps the calendar date of original and picks a random
+// time of day in [startSec, endSec) seconds since midnight.
+func RandomTimeOfDay(original time.Time, startSec, endSec int) time.Time {
+	y, mo, d := original.Date()
+	loc := original.Location()
+
+	randomSec := startSec + rng.IntN(endSec-startSec)
+	h := randomSec / 3600
+	m := (randomSec % 3600) / 60
+	sec := randomSec % 60
+
+	return time.Date(y, mo, d, h, m, sec, 0, loc)
+}
@@ -0,0 +1,121 @@
+package timestamp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// resolveNow handles "NOW" optionally followed by a shift, e.g. "NOW-2h" or
+// "NOW +30m". ok is false when raw does not start with NOW.
+func resolveNow(raw string, now time.Time) (t time.Time, ok bool, err error) {
+	if len(raw) < 3 || !strings.EqualFold(raw[:3], "NOW") {
+		return time.Time{}, false, nil
+	}
+
+	rest := strings.TrimSpace(raw[3:])
+	if rest == "" {
+		return now, true, nil
+	}
+	if !ContainsShift(rest) {
+		return time.Time{}, true, fmt.Errorf("invalid NOW expression %q: expected NOW followed by a shift like -2h", raw)
+	}
+
+	shift, err := ParseShift(rest)
+	if err != nil {
+		return time.Time{}, true, err
+	}
+	return now.Add(shift), true, nil
+}
+
+// resolveEpoch handles "@<unix-seconds>" optionally followed by a shift.
+// The instant is exact; the original timezone offset is kept.
+func resolveEpoch(raw string, original time.Time) (time.Time, error) {
+	tsStr, shiftExpr := splitTrailingShift(raw)
+	tsStr = strings.TrimSpace(tsStr)
+
+	secs, err := strconv.ParseInt(strings.TrimPrefix(tsStr, "@"), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid epoch timestamp %q", tsStr)
+	}
+
+	t := time.Unix(secs, 0)
+	if shiftExpr != "" {
+		shift, err := ParseShift(shiftExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		t = t.Add(shift)
+	}
+
+	return ApplyDelta(original, t.Sub(original)), nil
+}
+
+// expandRelative rewrites a relative date expression such as
+// "yesterday 14:00" or "last friday 09:30 +2h" into the absolute
+// "YYYY-MM-DD HH:MM:SS [shift]" form understood by resolveAbsoluteOrShift.
+// Dates are computed from now in the local timezone. When the time of day
+// is omitted, the commit's original (displayed) time of day is kept.
+//
+// ok is false when raw does not start with a relative date keyword.
+func expandRelative(raw string, original, now time.Time) (expanded string, ok bool, err error) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return "", false, nil
+	}
+
+	today := now.In(time.Local)
+	var days int
+	consumed := 1
+
+	switch strings.ToLower(fields[0]) {
+	case "today":
+		days = 0
+	case "yesterday":
+		days = -1
+	case "tomorrow":
+		days = 1
+	case "last":
+		if len(fields) < 2 {
+			return "", true, fmt.Errorf("expected a weekday after %q", fields[0])
+		}
+		wd, found := weekdays[strings.ToLower(fields[1])]
+		if !found {
+			return "", true, fmt.Errorf("unknown weekday %q", fields[1])
+		}
+		days = -(int(today.Weekday()-wd+7) % 7)
+		if days == 0 {
+			days = -7
+		}
+		consumed = 2
+	default:
+		return "", false, nil
+	}
+
+	y, m, d := today.Date()
+	date := time.Date(y, m, d+days, 0, 0, 0, 0, time.Local).Format("2006-01-02")
+
+	rest := fields[consumed:]
+	timeOfDay := FormatLocal(original)[len("2006-01-02 "):]
+	if len(rest) > 0 && !ContainsShift(rest[0]) {
+		timeOfDay = rest[0]
+		if strings.Count(timeOfDay, ":") == 1 {
+			timeOfDay += ":00"
+		}
+		rest = rest[1:]
+	}
+
+	parts := append([]string{date, timeOfDay}, rest...)
+	return strings.Join(parts, " "), true, nil
+}
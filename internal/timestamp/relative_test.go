@@ -0,0 +1,142 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveNow(t *testing.T) {
+	now := time.Date(2026, 2, 23, 15, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantOK  bool
+		wantErr bool
+	}{
+		{"NOW", now, true, false},
+		{"now", now, true, false},
+		{"NOW-2h", now.Add(-2 * time.Hour), true, false},
+		{"NOW +30m", now.Add(30 * time.Minute), true, false},
+		{"NOWHERE", time.Time{}, true, true},
+		{"2026-02-23 10:00:00", time.Time{}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok, err := resolveNow(tt.input, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("resolveNow(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveEpoch(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	orig := time.Date(2026, 2, 23, 10, 0, 0, 0, loc)
+
+	got, err := resolveEpoch("@1700000000", orig)
+	if err != nil {
+		t.Fatalf("resolveEpoch: %v", err)
+	}
+	if got.Unix() != 1700000000 {
+		t.Errorf("unix = %d, want 1700000000", got.Unix())
+	}
+	if _, offset := got.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("timezone offset changed: got %d", offset)
+	}
+
+	got, err = resolveEpoch("@1700000000 +1h", orig)
+	if err != nil {
+		t.Fatalf("resolveEpoch with shift: %v", err)
+	}
+	if got.Unix() != 1700003600 {
+		t.Errorf("unix = %d, want 1700003600", got.Unix())
+	}
+
+	if _, err := resolveEpoch("@abc", orig); err == nil {
+		t.Error("expected error for non-numeric epoch")
+	}
+}
+
+func TestExpandRelative(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2026, 2, 25, 15, 0, 0, 0, time.Local)
+	orig := time.Date(2026, 2, 20, 8, 15, 30, 0, time.Local)
+
+	tests := []struct {
+		input   string
+		want    string
+		wantOK  bool
+		wantErr bool
+	}{
+		{"today 14:00", "2026-02-25 14:00:00", true, false},
+		{"yesterday 14:00:05", "2026-02-24 14:00:05", true, false},
+		{"tomorrow 09:30", "2026-02-26 09:30:00", true, false},
+		{"yesterday", "2026-02-24 08:15:30", true, false},
+		{"last friday 09:30", "2026-02-20 09:30:00", true, false},
+		{"last Wednesday 09:30", "2026-02-18 09:30:00", true, false},
+		{"last monday 10:00 +2h", "2026-02-23 10:00:00 +2h", true, false},
+		{"yesterday +1h", "2026-02-24 08:15:30 +1h", true, false},
+		{"today RR(09,17):RR:00", "2026-02-25 RR(09,17):RR:00", true, false},
+		{"last someday", "", true, true},
+		{"last", "", true, true},
+		{"2026-02-23 10:00:00", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok, err := expandRelative(tt.input, orig, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expandRelative(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAll_Relative(t *testing.T) {
+	now := time.Date(2026, 2, 25, 15, 0, 0, 0, time.Local)
+	orig := time.Date(2026, 2, 20, 8, 0, 0, 0, time.Local)
+
+	commits := []Commit{
+		{Hash: "aaa", OrigAuthorDate: orig, OrigCommitDate: orig, EditedRaw: "yesterday 14:00"},
+		{Hash: "bbb", OrigAuthorDate: orig, OrigCommitDate: orig, EditedRaw: "NOW-2h"},
+	}
+
+	if err := ResolveAll(commits, now, false); err != nil {
+		t.Fatalf("ResolveAll: %v", err)
+	}
+
+	want := time.Date(2026, 2, 24, 14, 0, 0, 0, time.Local)
+	if !commits[0].ResolvedAuthorDate.Equal(want) {
+		t.Errorf("yesterday: expected %v, got %v", want, commits[0].ResolvedAuthorDate)
+	}
+	if !commits[1].ResolvedAuthorDate.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("NOW-2h: expected %v, got %v", now.Add(-2*time.Hour), commits[1].ResolvedAuthorDate)
+	}
+}
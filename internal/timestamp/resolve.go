@@ -29,7 +29,8 @@ type Commit struct {
 // ResolveAll resolves the EditedRaw fields of each commit into final
 // timestamps. The commits slice must be in oldest-first order.
 //
-// The now parameter is captured once and used for all NOW references.
+// The now parameter is captured once and used for all NOW references and
+// relative dates (today, yesterday, last friday, ...).
 // Bare shift expressions (e.g. "+1h30m") resolve relative to the previous
 // commit's already-resolved author date, so they chain naturally.
 func ResolveAll(commits []Commit, now time.Time, splitDates bool) error {
@@ -62,14 +63,42 @@ func ResolveAll(commits []Commit, now time.Time, splitDates bool) error {
 }
 
 func resolveOne(raw string, original time.Time, prevResolved *time.Time, now time.Time) (time.Time, error) {
+	// A trailing "~15m" snaps whatever the rest of the expression resolves to.
+	raw, snapExpr := splitTrailingSnap(raw)
+
+	resolved, err := resolveExpr(raw, original, prevResolved, now)
+	if err != nil || snapExpr == "" {
+		return resolved, err
+	}
+
+	rounding, err := ParseRounding(snapExpr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snap ~%s: %w", snapExpr, err)
+	}
+	return rounding.Apply(resolved), nil
+}
+
+func resolveExpr(raw string, original time.Time, prevResolved *time.Time, now time.Time) (time.Time, error) {
 	raw = strings.TrimSpace(raw)
 
 	if raw == "" {
 		return original, nil
 	}
 
-	if strings.ToUpper(raw) == "NOW" {
-		return now, nil
+	if t, ok, err := resolveNow(raw, now); ok {
+		return t, err
+	}
+
+	if strings.HasPrefix(raw, "@") {
+		return resolveEpoch(raw, original)
+	}
+
+	expanded, ok, err := expandRelative(raw, original, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ok {
+		raw = expanded
 	}
 
 	return resolveAbsoluteOrShift(raw, original, prevResolved)
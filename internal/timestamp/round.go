@@ -0,0 +1,107 @@
+package timestamp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RoundMode selects the direction used when snapping to a granularity.
+type RoundMode int
+
+const (
+	RoundNearest RoundMode = iota
+	RoundFloor
+	RoundCeil
+)
+
+// Rounding snaps timestamps to a multiple of Unit on the local wall clock.
+type Rounding struct {
+	Unit time.Duration
+	Mode RoundMode
+}
+
+// ParseRounding parses a granularity expression such as "15m" (nearest),
+// "-15m" (round down) or "+15m" (round up). Units are the same as for
+// shifts.
+func ParseRounding(expr string) (Rounding, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return Rounding{}, fmt.Errorf("empty rounding expression")
+	}
+
+	mode := RoundNearest
+	switch expr[0] {
+	case '-':
+		mode = RoundFloor
+		expr = expr[1:]
+	case '+':
+		mode = RoundCeil
+		expr = expr[1:]
+	}
+
+	unit, err := ParseShift("+" + expr)
+	if err != nil {
+		return Rounding{}, err
+	}
+	if unit <= 0 {
+		return Rounding{}, fmt.Errorf("rounding granularity must be positive: %q", expr)
+	}
+	return Rounding{Unit: unit, Mode: mode}, nil
+}
+
+// Apply snaps t to the granularity. The rounding happens on the displayed
+// (local) wall clock so "~1d" means local midnight, but the result keeps
+// t's original timezone offset.
+func (r Rounding) Apply(t time.Time) time.Time {
+	return r.applyIn(t, time.Local)
+}
+
+// applyIn snaps t on loc's wall clock, keeping t's own timezone.
+func (r Rounding) applyIn(t time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	wall := time.Duration(t.UnixNano()) + time.Duration(offset)*time.Second
+
+	rem := wall % r.Unit
+	if rem < 0 {
+		rem += r.Unit
+	}
+	if rem == 0 {
+		return t
+	}
+
+	switch r.Mode {
+	case RoundFloor:
+		return t.Add(-rem)
+	case RoundCeil:
+		return t.Add(r.Unit - rem)
+	default:
+		if rem*2 >= r.Unit {
+			return t.Add(r.Unit - rem)
+		}
+		return t.Add(-rem)
+	}
+}
+
+// RoundAll snaps the resolved author and committer dates of every commit.
+func RoundAll(commits []Commit, r Rounding) {
+	for i := range commits {
+		commits[i].ResolvedAuthorDate = r.Apply(commits[i].ResolvedAuthorDate)
+		commits[i].ResolvedCommitDate = r.Apply(commits[i].ResolvedCommitDate)
+	}
+}
+
+// splitTrailingSnap separates a trailing "~15m" snapping operator from the
+// timestamp string. Example: "2026-02-23 10:07:41 ~15m" -> ("2026-02-23 10:07:41", "15m")
+func splitTrailingSnap(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	idx := strings.LastIndex(s, " ")
+	lastToken := s[idx+1:]
+	if !strings.HasPrefix(lastToken, "~") {
+		return s, ""
+	}
+	if idx < 0 {
+		return "", lastToken[1:]
+	}
+	return s[:idx], lastToken[1:]
+}
@@ -0,0 +1,128 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRounding(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantUnit time.Duration
+		wantMode RoundMode
+		wantErr  bool
+	}{
+		{"15m", 15 * time.Minute, RoundNearest, false},
+		{"-5m", 5 * time.Minute, RoundFloor, false},
+		{"+1h", time.Hour, RoundCeil, false},
+		{"1d", 24 * time.Hour, RoundNearest, false},
+
+		// Errors.
+		{"", 0, 0, true},
+		{"0m", 0, 0, true},
+		{"15x", 0, 0, true},
+		{"m", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRounding(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for %q, got %v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Unit != tt.wantUnit || got.Mode != tt.wantMode {
+				t.Errorf("ParseRounding(%q) = %+v, want unit=%v mode=%v", tt.input, got, tt.wantUnit, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestRounding_Apply(t *testing.T) {
+	at := func(h, m, s int) time.Time {
+		return time.Date(2026, 2, 23, h, m, s, 0, time.Local)
+	}
+
+	tests := []struct {
+		name string
+		r    Rounding
+		in   time.Time
+		want time.Time
+	}{
+		{"nearest down", Rounding{15 * time.Minute, RoundNearest}, at(10, 7, 29), at(10, 0, 0)},
+		{"nearest up", Rounding{15 * time.Minute, RoundNearest}, at(10, 7, 41), at(10, 15, 0)},
+		{"floor", Rounding{15 * time.Minute, RoundFloor}, at(10, 14, 59), at(10, 0, 0)},
+		{"ceil", Rounding{15 * time.Minute, RoundCeil}, at(10, 0, 1), at(10, 15, 0)},
+		{"already aligned", Rounding{15 * time.Minute, RoundCeil}, at(10, 15, 0), at(10, 15, 0)},
+		{"day floor is local midnight", Rounding{24 * time.Hour, RoundFloor}, at(23, 59, 0), at(0, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.r.Apply(tt.in)
+			if !got.Equal(tt.want) {
+				t.Errorf("Apply(%s) = %s, want %s", FormatLocal(tt.in), FormatLocal(got), FormatLocal(tt.want))
+			}
+		})
+	}
+}
+
+func TestRounding_Apply_PreservesOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	in := time.Date(2026, 2, 23, 10, 7, 41, 0, loc)
+
+	got := Rounding{Unit: time.Minute, Mode: RoundFloor}.Apply(in)
+	if _, offset := got.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("timezone offset changed: got %d", offset)
+	}
+	if got.Second() != 0 {
+		t.Errorf("expected whole minute, got %v", got)
+	}
+}
+
+func TestSplitTrailingSnap(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantRest string
+		wantSnap string
+	}{
+		{"2026-02-23 10:07:41 ~15m", "2026-02-23 10:07:41", "15m"},
+		{"+1h ~-5m", "+1h", "-5m"},
+		{"~1h", "", "1h"},
+		{"2026-02-23 10:07:41", "2026-02-23 10:07:41", ""},
+	}
+
+	for _, tt := range tests {
+		rest, snap := splitTrailingSnap(tt.input)
+		if rest != tt.wantRest || snap != tt.wantSnap {
+			t.Errorf("splitTrailingSnap(%q) = (%q, %q), want (%q, %q)", tt.input, rest, snap, tt.wantRest, tt.wantSnap)
+		}
+	}
+}
+
+func TestResolveAll_Snap(t *testing.T) {
+	orig := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	commits := []Commit{
+		{Hash: "aaa", OrigAuthorDate: orig, OrigCommitDate: orig, EditedRaw: "2026-02-23 10:07:41 ~15m"},
+		{Hash: "bbb", OrigAuthorDate: orig, OrigCommitDate: orig, EditedRaw: "+7m ~+5m"},
+	}
+
+	if err := ResolveAll(commits, time.Now(), false); err != nil {
+		t.Fatalf("ResolveAll: %v", err)
+	}
+
+	want := time.Date(2026, 2, 23, 10, 15, 0, 0, time.Local)
+	if !commits[0].ResolvedAuthorDate.Equal(want) {
+		t.Errorf("snap: expected %v, got %v", want, commits[0].ResolvedAuthorDate)
+	}
+	// 10:15 + 7m = 10:22, rounded up to 10:25.
+	want = time.Date(2026, 2, 23, 10, 25, 0, 0, time.Local)
+	if !commits[1].ResolvedAuthorDate.Equal(want) {
+		t.Errorf("bare shift with snap: expected %v, got %v", want, commits[1].ResolvedAuthorDate)
+	}
+}
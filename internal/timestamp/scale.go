@@ -0,0 +1,79 @@
+package timestamp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scale multiplies the distance of every resolved date from anchor by
+// factor. Because every date is mapped by the same linear function, each
+// inter-commit gap is multiplied by factor too: order and relative rhythm
+// are preserved. Timezone offsets are kept.
+func Scale(commits []Commit, anchor time.Time, factor float64) {
+	for i := range commits {
+		c := &commits[i]
+		c.ResolvedAuthorDate = scaleFrom(c.ResolvedAuthorDate, anchor, anchor, factor)
+		c.ResolvedCommitDate = scaleFrom(c.ResolvedCommitDate, anchor, anchor, factor)
+	}
+}
+
+// FitInto linearly maps the span of resolved dates onto [start, end]: the
+// earliest date lands on start, the latest on end, and everything in
+// between keeps its relative position. Committer dates count towards the
+// span too, so none of them ends up outside [start, end] either.
+func FitInto(commits []Commit, start, end time.Time) {
+	if len(commits) == 0 {
+		return
+	}
+
+	first, last := commits[0].ResolvedAuthorDate, commits[0].ResolvedAuthorDate
+	for _, c := range commits {
+		for _, t := range []time.Time{c.ResolvedAuthorDate, c.ResolvedCommitDate} {
+			if t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+	}
+
+	factor := 0.0
+	if span := last.Sub(first); span > 0 {
+		factor = float64(end.Sub(start)) / float64(span)
+	}
+
+	for i := range commits {
+		c := &commits[i]
+		c.ResolvedAuthorDate = scaleFrom(c.ResolvedAuthorDate, first, start, factor)
+		c.ResolvedCommitDate = scaleFrom(c.ResolvedCommitDate, first, start, factor)
+	}
+}
+
+// ParseSpan parses "START..END" where both ends use DisplayLayout in the
+// local timezone.
+func ParseSpan(expr string) (start, end time.Time, err error) {
+	parts := strings.SplitN(expr, "..", 2)
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected START..END, got %q", expr)
+	}
+	start, err = ParseLocal(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err = ParseLocal(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("span end %s is before start %s", FormatLocal(end), FormatLocal(start))
+	}
+	return start, end, nil
+}
+
+// scaleFrom maps t to target + (t - anchor) * factor, keeping t's timezone.
+func scaleFrom(t, anchor, target time.Time, factor float64) time.Time {
+	scaled := target.Add(time.Duration(float64(t.Sub(anchor)) * factor))
+	return t.Add(scaled.Sub(t))
+}
@@ -0,0 +1,142 @@
+package timestamp
+
+import (
+	"testing"
+	"time"
+)
+
+func planAt(base time.Time, offsets ...time.Duration) []Commit {
+	commits := make([]Commit, len(offsets))
+	for i, d := range offsets {
+		commits[i] = Commit{ResolvedAuthorDate: base.Add(d), ResolvedCommitDate: base.Add(d)}
+	}
+	return commits
+}
+
+func TestScale_FirstAnchor(t *testing.T) {
+	base := time.Date(2026, 2, 1, 10, 0, 0, 0, time.Local)
+	commits := planAt(base, 0, 4*time.Hour, 24*time.Hour)
+
+	Scale(commits, base, 0.5)
+
+	want := []time.Duration{0, 2 * time.Hour, 12 * time.Hour}
+	for i, w := range want {
+		if !commits[i].ResolvedAuthorDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d: expected %v, got %v", i, base.Add(w), commits[i].ResolvedAuthorDate)
+		}
+		if !commits[i].ResolvedCommitDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d committer: expected %v, got %v", i, base.Add(w), commits[i].ResolvedCommitDate)
+		}
+	}
+}
+
+func TestScale_LastAnchor(t *testing.T) {
+	base := time.Date(2026, 2, 1, 10, 0, 0, 0, time.Local)
+	commits := planAt(base, 0, 4*time.Hour, 8*time.Hour)
+
+	Scale(commits, base.Add(8*time.Hour), 2)
+
+	want := []time.Duration{-8 * time.Hour, 0, 8 * time.Hour}
+	for i, w := range want {
+		if !commits[i].ResolvedAuthorDate.Equal(base.Add(w)) {
+			t.Errorf("commit %d: expected %v, got %v", i, base.Add(w), commits[i].ResolvedAuthorDate)
+		}
+	}
+}
+
+func TestScale_PreservesOffset(t *testing.T) {
+	loc := time.FixedZone("IST", 5*3600+30*60)
+	ts := time.Date(2026, 2, 23, 10, 0, 0, 0, loc)
+	commits := []Commit{{ResolvedAuthorDate: ts, ResolvedCommitDate: ts}}
+
+	Scale(commits, ts.Add(-time.Hour), 3)
+
+	if _, offset := commits[0].ResolvedAuthorDate.Zone(); offset != 5*3600+30*60 {
+		t.Errorf("timezone offset changed: got %d", offset)
+	}
+	if !commits[0].ResolvedAuthorDate.Equal(ts.Add(2 * time.Hour)) {
+		t.Errorf("expected %v, got %v", ts.Add(2*time.Hour), commits[0].ResolvedAuthorDate)
+	}
+}
+
+func TestFitInto(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+	// A month of sporadic commits.
+	commits := planAt(base, 0, 3*24*time.Hour, 10*24*time.Hour, 30*24*time.Hour)
+
+	start := time.Date(2026, 2, 23, 9, 0, 0, 0, time.Local)
+	end := start.Add(120 * time.Hour)
+	FitInto(commits, start, end)
+
+	want := []time.Duration{0, 12 * time.Hour, 40 * time.Hour, 120 * time.Hour}
+	for i, w := range want {
+		if !commits[i].ResolvedAuthorDate.Equal(start.Add(w)) {
+			t.Errorf("commit %d: expected %v, got %v", i, start.Add(w), commits[i].ResolvedAuthorDate)
+		}
+	}
+}
+
+func TestFitInto_SingleInstant(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+	commits := planAt(base, 0, 0)
+
+	start := time.Date(2026, 2, 23, 9, 0, 0, 0, time.Local)
+	FitInto(commits, start, start.Add(time.Hour))
+
+	for i, c := range commits {
+		if !c.ResolvedAuthorDate.Equal(start) {
+			t.Errorf("commit %d: expected %v, got %v", i, start, c.ResolvedAuthorDate)
+		}
+	}
+}
+
+func TestFitInto_LateCommitterDates(t *testing.T) {
+	// Authored through January, all committed on March 1st.
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.Local)
+	commits := planAt(base, 0, 15*24*time.Hour, 30*24*time.Hour)
+	committed := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	for i := range commits {
+		commits[i].ResolvedCommitDate = committed
+	}
+
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	end := time.Date(2026, 10, 7, 17, 0, 0, 0, time.Local)
+	FitInto(commits, start, end)
+
+	if !commits[0].ResolvedAuthorDate.Equal(start) {
+		t.Errorf("expected the first author date on %v, got %v", start, commits[0].ResolvedAuthorDate)
+	}
+	for i, c := range commits {
+		for _, d := range []time.Time{c.ResolvedAuthorDate, c.ResolvedCommitDate} {
+			if d.Before(start) || d.After(end) {
+				t.Errorf("commit %d: %v is outside %v..%v", i, d, start, end)
+			}
+		}
+		if c.ResolvedCommitDate.Before(c.ResolvedAuthorDate) {
+			t.Errorf("commit %d: committed before it was authored", i)
+		}
+	}
+	if !commits[2].ResolvedCommitDate.Equal(end) {
+		t.Errorf("expected the latest committer date on %v, got %v", end, commits[2].ResolvedCommitDate)
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	start, end, err := ParseSpan("2026-02-23 09:00:00..2026-02-27 18:00:00")
+	if err != nil {
+		t.Fatalf("ParseSpan: %v", err)
+	}
+	if FormatLocal(start) != "2026-02-23 09:00:00" || FormatLocal(end) != "2026-02-27 18:00:00" {
+		t.Errorf("ParseSpan = %s..%s", FormatLocal(start), FormatLocal(end))
+	}
+
+	for _, bad := range []string{
+		"2026-02-23 09:00:00",
+		"2026-02-27 18:00:00..2026-02-23 09:00:00",
+		"yesterday..today",
+	} {
+		if _, _, err := ParseSpan(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -9,24 +9,43 @@ import (
 )
 
 // Generate produces the .git-retime-todo file content from a list of commits.
-// Commits must be in oldest-first order.
+// Commits must be in oldest-first order. Context commits are written as
+// comments so they show where the selected commits sit without being editable.
 func Generate(commits []git.CommitInfo, base string, splitDates bool) string {
 	var b strings.Builder
 
+	selected := 0
+	for _, c := range commits {
+		if !c.Context {
+			selected++
+		}
+	}
+
+	count := fmt.Sprintf("%d commit(s)", selected)
+	if selected != len(commits) {
+		count = fmt.Sprintf("%d of %d commit(s)", selected, len(commits))
+	}
 	if base != "" {
-		fmt.Fprintf(&b, "# Retime %d commit(s) onto %s\n", len(commits), base[:minInt(7, len(base))])
+		fmt.Fprintf(&b, "# Retime %s onto %s\n", count, base[:minInt(7, len(base))])
 	} else {
-		fmt.Fprintf(&b, "# Retime %d commit(s) (root)\n", len(commits))
+		fmt.Fprintf(&b, "# Retime %s (root)\n", count)
+	}
+	if selected != len(commits) {
+		b.WriteString("# Commented-out commit lines do not match the filters and keep their dates.\n")
 	}
 	b.WriteString("#\n")
 
 	for _, c := range commits {
+		prefix := ""
+		if c.Context {
+			prefix = "# "
+		}
 		ts := timestamp.FormatLocal(c.AuthorDate)
 		if splitDates {
 			ts2 := timestamp.FormatLocal(c.CommitDate)
-			fmt.Fprintf(&b, "%s  %s  %s  %s\n", c.ShortHash, ts, ts2, c.Subject)
+			fmt.Fprintf(&b, "%s%s  %s  %s  %s\n", prefix, c.ShortHash, ts, ts2, c.Subject)
 		} else {
-			fmt.Fprintf(&b, "%s  %s  %s\n", c.ShortHash, ts, c.Subject)
+			fmt.Fprintf(&b, "%s%s  %s  %s\n", prefix, c.ShortHash, ts, c.Subject)
 		}
 	}
 
@@ -0,0 +1,34 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfnzdeh/git-retime/internal/git"
+)
+
+func TestGenerate_ContextCommitsAreComments(t *testing.T) {
+	ts := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	commits := []git.CommitInfo{
+		{ShortHash: "abc1234", AuthorDate: ts, CommitDate: ts, Subject: "First", Context: true},
+		{ShortHash: "def5678", AuthorDate: ts, CommitDate: ts, Subject: "Second"},
+	}
+
+	content := Generate(commits, "0123456789", false)
+
+	if !strings.Contains(content, "# Retime 1 of 2 commit(s) onto 0123456") {
+		t.Errorf("expected selected count in header, got:\n%s", content)
+	}
+	if !strings.Contains(content, "# abc1234  2026-02-23 10:00:00  First\n") {
+		t.Errorf("expected context commit as a comment, got:\n%s", content)
+	}
+
+	entries, err := Parse(content, false)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Hash != "def5678" {
+		t.Errorf("expected only the selected commit to parse, got %+v", entries)
+	}
+}
@@ -5,30 +5,41 @@ import "strings"
 // HelpBlock returns the commented-out syntax cheat sheet appended to the
 // bottom of the .git-retime-todo file.
 func HelpBlock(splitDates bool) string {
+	if splitDates {
+		return helpBlock("<hash>  <author-date>  <committer-date>  <message>", "commit")
+	}
+	return helpBlock("<hash>  <timestamp>  <message>", "commit")
+}
+
+// TagHelpBlock is the cheat sheet for the tags todo.
+func TagHelpBlock() string {
+	return helpBlock("<tag>  <tagger-date>  <subject>", "tag")
+}
+
+func helpBlock(format, noun string) string {
 	var b strings.Builder
 
 	b.WriteString("# --- Syntax Reference ---\n")
 	b.WriteString("#\n")
-
-	if splitDates {
-		b.WriteString("# Format: <hash>  <author-date>  <committer-date>  <message>\n")
-	} else {
-		b.WriteString("# Format: <hash>  <timestamp>  <message>\n")
-	}
-
+	b.WriteString("# Format: " + format + "\n")
 	b.WriteString("#\n")
 	b.WriteString("# Timestamps are displayed in your local timezone.\n")
-	b.WriteString("# Edit the timestamp column to change commit dates.\n")
-	b.WriteString("# The commit message (last column) is also editable.\n")
+	b.WriteString("# Edit the timestamp column to change " + noun + " dates.\n")
+	b.WriteString("# The " + noun + " message (last column) is also editable.\n")
 	b.WriteString("#\n")
 	b.WriteString("# Commands:\n")
 	b.WriteString("#   (leave unchanged)          Keep the original timestamp\n")
 	b.WriteString("#   2026-02-23 14:00:00        Set an absolute time\n")
 	b.WriteString("#   2026-02-23 10:00:00 +2h    Shift from the written time\n")
-	b.WriteString("#   +2h, -30m, +1d2h30m        Shift from the previous commit's new time\n")
-	b.WriteString("#   NOW                        Current time (identical for all NOW commits)\n")
+	b.WriteString("#   +2h, -30m, +1d2h30m        Shift from the previous " + noun + "'s new time\n")
+	b.WriteString("#   NOW                        Current time (identical for all NOW " + noun + "s)\n")
+	b.WriteString("#   NOW-2h                     Current time with a shift\n")
+	b.WriteString("#   yesterday 14:00            Relative day: today, yesterday, tomorrow\n")
+	b.WriteString("#   last friday 09:30          Most recent weekday before today\n")
+	b.WriteString("#   @1700000000                Unix epoch seconds\n")
 	b.WriteString("#   RR or RR(08,17)            Randomize a time field (HH:MM:SS only)\n")
 	b.WriteString("#     e.g. 2026-02-23 RR(09,17):RR:00\n")
+	b.WriteString("#   ... ~15m                   Snap to nearest 15m (~-15m down, ~+15m up)\n")
 	b.WriteString("#\n")
 	b.WriteString("# Units: w=weeks, d=days, h=hours, m=minutes, s=seconds\n")
 	b.WriteString("# Compound shifts: +1d2h30m (1 day, 2 hours, 30 minutes)\n")
@@ -0,0 +1,78 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erfnzdeh/git-retime/internal/git"
+	"github.com/erfnzdeh/git-retime/internal/timestamp"
+)
+
+// GenerateTags produces the todo content for retiming annotated tags. Tags
+// must be in oldest-first order; the tag name takes the place of the hash.
+func GenerateTags(tags []git.TagInfo) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Retime %d annotated tag(s)\n", len(tags))
+	b.WriteString("#\n")
+	for _, t := range tags {
+		fmt.Fprintf(&b, "%s  %s  %s\n", t.Name, timestamp.FormatLocal(t.TaggerDate), t.Subject)
+	}
+	b.WriteString("#\n")
+	b.WriteString(TagHelpBlock())
+
+	return b.String()
+}
+
+// ValidateTagStructure checks that the parsed entries name the same tags in
+// the same order as the originals.
+func ValidateTagStructure(entries []ParsedEntry, originals []git.TagInfo) error {
+	if len(entries) < len(originals) {
+		present := make(map[string]bool, len(entries))
+		for _, e := range entries {
+			present[e.Hash] = true
+		}
+		var missing []string
+		for _, o := range originals {
+			if !present[o.Name] {
+				missing = append(missing, o.Name)
+			}
+		}
+		return fmt.Errorf("tag(s) deleted from todo file: %s\ngit-retime only modifies timestamps — do not remove lines", strings.Join(missing, ", "))
+	}
+
+	if len(entries) > len(originals) {
+		return fmt.Errorf("extra lines in todo file: expected %d tags, found %d", len(originals), len(entries))
+	}
+
+	for i, e := range entries {
+		if e.Hash != originals[i].Name {
+			return fmt.Errorf("tag order changed at line %d: expected %s, got %s\ngit-retime only modifies timestamps — do not reorder lines", i+1, originals[i].Name, e.Hash)
+		}
+	}
+
+	return nil
+}
+
+// TagsToCommits converts parsed tag entries into timestamp.Commit structs so
+// they can be resolved like commits. The tag name is carried in Hash and the
+// tagger date in both original dates.
+func TagsToCommits(entries []ParsedEntry, originals []git.TagInfo) ([]timestamp.Commit, error) {
+	if len(entries) != len(originals) {
+		return nil, fmt.Errorf("entry count (%d) does not match original tag count (%d)", len(entries), len(originals))
+	}
+
+	commits := make([]timestamp.Commit, len(entries))
+	for i, e := range entries {
+		orig := originals[i]
+		commits[i] = timestamp.Commit{
+			Hash:           orig.Name,
+			OrigAuthorDate: orig.TaggerDate,
+			OrigCommitDate: orig.TaggerDate,
+			Subject:        orig.Subject,
+			EditedRaw:      e.RawTS,
+			NewSubject:     e.Subject,
+		}
+	}
+	return commits, nil
+}
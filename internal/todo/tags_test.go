@@ -0,0 +1,59 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfnzdeh/git-retime/internal/git"
+)
+
+func testTags() []git.TagInfo {
+	ts := time.Date(2026, 2, 23, 10, 0, 0, 0, time.Local)
+	return []git.TagInfo{
+		{Name: "v1.0", TaggerDate: ts, Subject: "Release 1.0"},
+		{Name: "v1.1", TaggerDate: ts.Add(time.Hour), Subject: "Release 1.1"},
+	}
+}
+
+func TestGenerateTags_RoundTrip(t *testing.T) {
+	tags := testTags()
+	content := GenerateTags(tags)
+
+	if !strings.Contains(content, "v1.0  2026-02-23 10:00:00  Release 1.0\n") {
+		t.Errorf("expected tag line, got:\n%s", content)
+	}
+
+	entries, err := Parse(content, false)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := ValidateTagStructure(entries, tags); err != nil {
+		t.Fatalf("ValidateTagStructure: %v", err)
+	}
+	commits, err := TagsToCommits(entries, tags)
+	if err != nil {
+		t.Fatalf("TagsToCommits: %v", err)
+	}
+	if commits[1].Hash != "v1.1" || commits[1].EditedRaw != "2026-02-23 11:00:00" {
+		t.Errorf("unexpected commit %+v", commits[1])
+	}
+}
+
+func TestValidateTagStructure_Deleted(t *testing.T) {
+	entries := []ParsedEntry{{Hash: "v1.1", RawTS: "2026-02-23 11:00:00"}}
+	err := ValidateTagStructure(entries, testTags())
+	if err == nil || !strings.Contains(err.Error(), "v1.0") {
+		t.Errorf("expected deleted tag v1.0 to be reported, got %v", err)
+	}
+}
+
+func TestValidateTagStructure_Reordered(t *testing.T) {
+	entries := []ParsedEntry{
+		{Hash: "v1.1", RawTS: "2026-02-23 11:00:00"},
+		{Hash: "v1.0", RawTS: "2026-02-23 10:00:00"},
+	}
+	if err := ValidateTagStructure(entries, testTags()); err == nil {
+		t.Error("expected reorder error")
+	}
+}
@@ -26,9 +26,13 @@ func IsAbort(content string) bool {
 	return true
 }
 
-// ValidateStructure checks that the parsed entries have the same hashes in the
-// same order as the original commits. Returns an error if lines were deleted
-// or reordered.
+// ValidateStructure checks that the parsed entries name the same commits in
+// the same order as the original commits. Returns an error if lines were
+// deleted or reordered.
+//
+// A todo hash names a commit when it is a prefix of its full object ID, so
+// lines keep working whatever abbreviation length (or hash algorithm) the
+// repository uses; a prefix matching several of the commits is rejected.
 func ValidateStructure(entries []ParsedEntry, originals []git.CommitInfo) error {
 	if len(entries) < len(originals) {
 		missing := findMissing(entries, originals)
@@ -40,24 +44,36 @@ func ValidateStructure(entries []ParsedEntry, originals []git.CommitInfo) error
 	}
 
 	for i, e := range entries {
-		origShort := originals[i].ShortHash
-		if e.Hash != origShort {
-			return fmt.Errorf("commit order changed at line %d: expected %s, got %s\ngit-retime only modifies timestamps — do not reorder lines", i+1, origShort, e.Hash)
+		if !matchesCommit(e.Hash, originals[i]) {
+			return fmt.Errorf("commit order changed at line %d: expected %s, got %s\ngit-retime only modifies timestamps — do not reorder lines", i+1, originals[i].ShortHash, e.Hash)
+		}
+		for j, o := range originals {
+			if j != i && matchesCommit(e.Hash, o) {
+				return fmt.Errorf("ambiguous commit %s at line %d: matches both %s and %s", e.Hash, i+1, originals[i].ShortHash, o.ShortHash)
+			}
 		}
 	}
 
 	return nil
 }
 
-func findMissing(entries []ParsedEntry, originals []git.CommitInfo) []string {
-	present := make(map[string]bool, len(entries))
-	for _, e := range entries {
-		present[e.Hash] = true
-	}
+// matchesCommit reports whether hash, as written in the todo, is a prefix
+// of the commit's full object ID. Like git, it needs at least 4 digits.
+func matchesCommit(hash string, c git.CommitInfo) bool {
+	return len(hash) >= 4 && strings.HasPrefix(c.Hash, strings.ToLower(hash))
+}
 
+func findMissing(entries []ParsedEntry, originals []git.CommitInfo) []string {
 	var missing []string
 	for _, o := range originals {
-		if !present[o.ShortHash] {
+		found := false
+		for _, e := range entries {
+			if matchesCommit(e.Hash, o) {
+				found = true
+				break
+			}
+		}
+		if !found {
 			missing = append(missing, o.ShortHash)
 		}
 	}
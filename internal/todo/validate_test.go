@@ -1,6 +1,7 @@
 package todo
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/erfnzdeh/git-retime/internal/git"
@@ -39,8 +40,8 @@ func TestValidateStructure_Valid(t *testing.T) {
 		{Hash: "def5678", RawTS: "2026-02-23 11:00:00", Subject: "Second"},
 	}
 	originals := []git.CommitInfo{
-		{ShortHash: "abc1234", Subject: "First"},
-		{ShortHash: "def5678", Subject: "Second"},
+		{Hash: "abc1234abc1234abc1234abc1234abc1234abc1", ShortHash: "abc1234", Subject: "First"},
+		{Hash: "def5678def5678def5678def5678def5678def5", ShortHash: "def5678", Subject: "Second"},
 	}
 
 	if err := ValidateStructure(entries, originals); err != nil {
@@ -53,8 +54,8 @@ func TestValidateStructure_Deleted(t *testing.T) {
 		{Hash: "abc1234"},
 	}
 	originals := []git.CommitInfo{
-		{ShortHash: "abc1234"},
-		{ShortHash: "def5678"},
+		{Hash: "abc1234abc1234abc1234abc1234abc1234abc1", ShortHash: "abc1234"},
+		{Hash: "def5678def5678def5678def5678def5678def5", ShortHash: "def5678"},
 	}
 
 	err := ValidateStructure(entries, originals)
@@ -69,8 +70,8 @@ func TestValidateStructure_Reordered(t *testing.T) {
 		{Hash: "abc1234"},
 	}
 	originals := []git.CommitInfo{
-		{ShortHash: "abc1234"},
-		{ShortHash: "def5678"},
+		{Hash: "abc1234abc1234abc1234abc1234abc1234abc1", ShortHash: "abc1234"},
+		{Hash: "def5678def5678def5678def5678def5678def5", ShortHash: "def5678"},
 	}
 
 	err := ValidateStructure(entries, originals)
@@ -86,8 +87,8 @@ func TestValidateStructure_Extra(t *testing.T) {
 		{Hash: "ghi9012"},
 	}
 	originals := []git.CommitInfo{
-		{ShortHash: "abc1234"},
-		{ShortHash: "def5678"},
+		{Hash: "abc1234abc1234abc1234abc1234abc1234abc1", ShortHash: "abc1234"},
+		{Hash: "def5678def5678def5678def5678def5678def5", ShortHash: "def5678"},
 	}
 
 	err := ValidateStructure(entries, originals)
@@ -95,3 +96,25 @@ func TestValidateStructure_Extra(t *testing.T) {
 		t.Error("expected error for extra lines")
 	}
 }
+
+func TestValidateStructure_Prefixes(t *testing.T) {
+	originals := []git.CommitInfo{
+		{Hash: "abc1234abc1234abc1234abc1234abc1234abc1", ShortHash: "abc1234"},
+		{Hash: "abc1299def5678def5678def5678def5678def5", ShortHash: "abc1299"},
+	}
+
+	long := []ParsedEntry{{Hash: "abc1234abc12"}, {Hash: "ABC1299"}}
+	if err := ValidateStructure(long, originals); err != nil {
+		t.Errorf("unexpected error for longer and upper-case prefixes: %v", err)
+	}
+
+	ambiguous := []ParsedEntry{{Hash: "abc12"}, {Hash: "abc1299"}}
+	if err := ValidateStructure(ambiguous, originals); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected ambiguous prefix error, got %v", err)
+	}
+
+	tooShort := []ParsedEntry{{Hash: "abc"}, {Hash: "abc1299"}}
+	if err := ValidateStructure(tooShort, originals); err == nil {
+		t.Error("expected error for a prefix shorter than 4 digits")
+	}
+}